@@ -0,0 +1,101 @@
+package analytics
+
+import "sort"
+
+// PairStats are the association measures of two products bought together.
+//
+//	Support      share of baskets containing both
+//	ConfidenceAB share of baskets with A that also contain B
+//	Lift         how much more often they occur together than if independent
+type PairStats struct {
+	A, B         int
+	Count        int
+	Support      float64
+	ConfidenceAB float64
+	ConfidenceBA float64
+	Lift         float64
+}
+
+// BasketStats is the result of AnalyzeBaskets
+type BasketStats struct {
+	Baskets    int
+	ItemCounts map[int]int
+	Pairs      []PairStats
+}
+
+// AnalyzeBaskets computes pair statistics over baskets of product ids.
+// Duplicate ids inside a basket count once. Pairs seen in fewer than
+// minCount baskets are dropped. Pairs are returned with A < B, sorted by
+// lift, then count.
+func AnalyzeBaskets(baskets [][]int, minCount int) BasketStats {
+	stats := BasketStats{ItemCounts: map[int]int{}}
+	pairCounts := map[[2]int]int{}
+
+	for _, basket := range baskets {
+		items := unique(basket)
+		if len(items) == 0 {
+			continue
+		}
+		stats.Baskets++
+
+		for i, a := range items {
+			stats.ItemCounts[a]++
+			for _, b := range items[i+1:] {
+				pairCounts[[2]int{a, b}]++
+			}
+		}
+	}
+
+	if stats.Baskets == 0 {
+		return stats
+	}
+
+	n := float64(stats.Baskets)
+	for pair, count := range pairCounts {
+		if count < minCount {
+			continue
+		}
+
+		countA := float64(stats.ItemCounts[pair[0]])
+		countB := float64(stats.ItemCounts[pair[1]])
+		stats.Pairs = append(stats.Pairs, PairStats{
+			A:            pair[0],
+			B:            pair[1],
+			Count:        count,
+			Support:      float64(count) / n,
+			ConfidenceAB: float64(count) / countA,
+			ConfidenceBA: float64(count) / countB,
+			Lift:         float64(count) * n / (countA * countB),
+		})
+	}
+
+	sort.Slice(stats.Pairs, func(i, j int) bool {
+		a, b := stats.Pairs[i], stats.Pairs[j]
+		if a.Lift != b.Lift {
+			return a.Lift > b.Lift
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.A != b.A {
+			return a.A < b.A
+		}
+		return a.B < b.B
+	})
+
+	return stats
+}
+
+// unique returns the distinct ids of a basket in ascending order
+func unique(basket []int) []int {
+	seen := map[int]bool{}
+	items := []int{}
+	for _, id := range basket {
+		if !seen[id] {
+			seen[id] = true
+			items = append(items, id)
+		}
+	}
+	sort.Ints(items)
+	return items
+}
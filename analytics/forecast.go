@@ -0,0 +1,175 @@
+package analytics
+
+import "math"
+
+// Forecast methods
+const (
+	MethodMovingAverage = "moving_average"
+	MethodSeasonal      = "seasonal"
+)
+
+// Smoothing parameters of the seasonal method
+const (
+	SeasonLength   = 7
+	SmoothingAlpha = 0.3
+	SmoothingGamma = 0.2
+	MovingWindow   = 28
+)
+
+// Method projects the next horizon values of a daily series
+type Method func(history []float64, horizon int) []float64
+
+// Methods are the forecast methods by name
+var Methods = map[string]Method{
+	MethodMovingAverage: MovingAverage,
+	MethodSeasonal:      SeasonalSmoothing,
+}
+
+// MethodNames lists the methods in a stable order
+var MethodNames = []string{MethodMovingAverage, MethodSeasonal}
+
+// MovingAverage projects the mean of the last MovingWindow days
+func MovingAverage(history []float64, horizon int) []float64 {
+	window := history
+	if len(window) > MovingWindow {
+		window = window[len(window)-MovingWindow:]
+	}
+
+	mean := 0.0
+	for _, v := range window {
+		mean += v
+	}
+	if len(window) > 0 {
+		mean /= float64(len(window))
+	}
+
+	forecast := make([]float64, horizon)
+	for i := range forecast {
+		forecast[i] = mean
+	}
+	return forecast
+}
+
+// SeasonalSmoothing is exponential smoothing with an additive weekly
+// season. The first value of the series is taken as season position 0, so
+// forecasts line up with the same weekdays as the history. With less than
+// two weeks of history there is no season to learn and it falls back to
+// plain exponential smoothing.
+func SeasonalSmoothing(history []float64, horizon int) []float64 {
+	forecast := make([]float64, horizon)
+	if len(history) == 0 {
+		return forecast
+	}
+
+	season := make([]float64, SeasonLength)
+	level := history[0]
+
+	if len(history) >= 2*SeasonLength {
+		// initial level and season from the complete weeks
+		weeks := len(history) / SeasonLength
+		level = 0
+		for _, v := range history[:weeks*SeasonLength] {
+			level += v
+		}
+		level /= float64(weeks * SeasonLength)
+
+		for i := 0; i < weeks*SeasonLength; i++ {
+			season[i%SeasonLength] += history[i] / float64(weeks)
+		}
+		for i := range season {
+			season[i] -= level
+		}
+	}
+
+	for t, y := range history {
+		s := season[t%SeasonLength]
+		level = SmoothingAlpha*(y-s) + (1-SmoothingAlpha)*level
+		season[t%SeasonLength] = SmoothingGamma*(y-level) + (1-SmoothingGamma)*s
+	}
+
+	for h := range forecast {
+		forecast[h] = math.Max(0, level+season[(len(history)+h)%SeasonLength])
+	}
+	return forecast
+}
+
+// Accuracy are the backtest errors of a method over one or more series
+//
+//	MAE  mean absolute error per day
+//	RMSE root mean squared error per day
+//	WAPE total absolute error as a share of the total actual demand
+//	Bias mean error per day; positive means over-forecasting
+type Accuracy struct {
+	Series int
+	Points int
+	MAE    float64
+	RMSE   float64
+	WAPE   float64
+	Bias   float64
+}
+
+// Backtest hides the last holdout days of every series, forecasts them
+// from the days before and compares. Series shorter than holdout plus one
+// week are skipped.
+func Backtest(series [][]float64, holdout int, method Method) Accuracy {
+	var acc Accuracy
+	var absErr, sqErr, err, actual float64
+
+	for _, history := range series {
+		if holdout <= 0 || len(history) < holdout+SeasonLength {
+			continue
+		}
+
+		train := history[:len(history)-holdout]
+		test := history[len(history)-holdout:]
+		forecast := method(train, holdout)
+
+		acc.Series++
+		for i, y := range test {
+			e := forecast[i] - y
+			absErr += math.Abs(e)
+			sqErr += e * e
+			err += e
+			actual += y
+			acc.Points++
+		}
+	}
+
+	if acc.Points == 0 {
+		return acc
+	}
+
+	n := float64(acc.Points)
+	acc.MAE = absErr / n
+	acc.RMSE = math.Sqrt(sqErr / n)
+	acc.Bias = err / n
+	if actual > 0 {
+		acc.WAPE = absErr / actual
+	}
+	return acc
+}
+
+// DaysOfStock is how many days stock lasts under the forecast. Past the
+// end of the forecast the average forecast rate is assumed. It returns
+// false when the forecast has no demand at all.
+func DaysOfStock(stock float64, forecast []float64) (float64, bool) {
+	if stock <= 0 {
+		return 0, true
+	}
+
+	remaining := stock
+	total := 0.0
+	for i, q := range forecast {
+		if q >= remaining {
+			return float64(i) + remaining/q, true
+		}
+		remaining -= q
+		total += q
+	}
+
+	if total == 0 {
+		return 0, false
+	}
+	rate := total / float64(len(forecast))
+	return float64(len(forecast)) + remaining/rate, true
+}
@@ -0,0 +1,154 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+// weekly is a week of demand with a weekend peak
+var weekly = []float64{2, 2, 2, 2, 2, 8, 10}
+
+// repeat lays pattern out for days days
+func repeat(pattern []float64, days int) []float64 {
+	series := make([]float64, days)
+	for i := range series {
+		series[i] = pattern[i%len(pattern)]
+	}
+	return series
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []float64
+		horizon int
+		want    float64
+	}{
+		{"empty history", nil, 3, 0},
+		{"shorter than the window", []float64{1, 2, 3, 6}, 2, 3},
+		{"whole weeks", repeat(weekly, 4*7), 7, 4},
+		// only the last 28 days count: 4 weeks of weekly after a spike
+		{"window", append([]float64{1000, 1000}, repeat(weekly, 4*7)...), 5, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MovingAverage(tt.history, tt.horizon)
+			if len(got) != tt.horizon {
+				t.Fatalf("got %d values, want %d", len(got), tt.horizon)
+			}
+			for i, v := range got {
+				if !near(v, tt.want) {
+					t.Errorf("day %d = %v, want %v", i, v, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSeasonalSmoothing(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []float64
+		horizon int
+		want    []float64
+	}{
+		{"empty history", nil, 2, []float64{0, 0}},
+		// less than two weeks falls back to plain smoothing
+		{"flat short history", repeat([]float64{5}, 10), 3, []float64{5, 5, 5}},
+		// a clean pattern is learned exactly and continues on the same weekdays
+		{"whole weeks", repeat(weekly, 8*7), 7, weekly},
+		{"partial week", repeat(weekly, 8*7+3), 4, []float64{2, 2, 8, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SeasonalSmoothing(tt.history, tt.horizon)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d values, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !near(got[i], tt.want[i]) {
+					t.Errorf("day %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	history := [][]float64{
+		repeat(weekly, 8*7),
+		repeat(weekly, 8*7),
+		repeat(weekly, 10), // too short for a 7 day holdout, skipped
+	}
+
+	// the moving average forecasts the weekly mean of 4 every day, off by
+	// 2, 2, 2, 2, 2, -4 and -6 over a week of 28 sold
+	tests := []struct {
+		name    string
+		holdout int
+		method  Method
+		want    Accuracy
+	}{
+		{"seasonal", 7, SeasonalSmoothing, Accuracy{Series: 2, Points: 14}},
+		{"moving average", 7, MovingAverage, Accuracy{
+			Series: 2,
+			Points: 14,
+			MAE:    20.0 / 7,
+			RMSE:   math.Sqrt(72.0 / 7),
+			WAPE:   20.0 / 28,
+			Bias:   0,
+		}},
+		{"no holdout", 0, MovingAverage, Accuracy{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Backtest(history, tt.holdout, tt.method)
+			if got.Series != tt.want.Series || got.Points != tt.want.Points {
+				t.Fatalf("series %d, points %d; want %d, %d", got.Series, got.Points, tt.want.Series, tt.want.Points)
+			}
+			if !near(got.MAE, tt.want.MAE) || !near(got.RMSE, tt.want.RMSE) ||
+				!near(got.WAPE, tt.want.WAPE) || !near(got.Bias, tt.want.Bias) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	seasonal := Backtest(history, 7, SeasonalSmoothing)
+	moving := Backtest(history, 7, MovingAverage)
+	if seasonal.WAPE >= moving.WAPE {
+		t.Errorf("seasonal WAPE %v should beat moving average %v on a weekly pattern", seasonal.WAPE, moving.WAPE)
+	}
+}
+
+func TestDaysOfStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		stock    float64
+		forecast []float64
+		want     float64
+		wantOK   bool
+	}{
+		{"no stock", 0, []float64{1, 1}, 0, true},
+		{"negative stock", -3, []float64{1, 1}, 0, true},
+		{"no demand", 10, []float64{0, 0, 0}, 0, false},
+		{"runs out mid-horizon", 5, []float64{2, 2, 2, 2}, 2.5, true},
+		{"runs out on the last day", 4, []float64{2, 2}, 2, true},
+		{"lasts past the horizon", 10, []float64{2, 2}, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DaysOfStock(tt.stock, tt.forecast)
+			if ok != tt.wantOK || !near(got, tt.want) {
+				t.Errorf("DaysOfStock(%v, %v) = %v, %v; want %v, %v", tt.stock, tt.forecast, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
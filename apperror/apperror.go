@@ -0,0 +1,228 @@
+// Package apperror holds the typed errors the services and repositories
+// return, and writes them as the API's JSON error envelope:
+//
+//	{"error": {"code": "not_found", "message": "produk tidak ditemukan", "details": {...}}}
+//
+// The code is what clients switch on; the message is for people. Errors
+// are made from an i18n message key, and the message is written in the
+// language negotiated for the request.
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task-crud-kategori/i18n"
+)
+
+// Code is the machine readable kind of an error
+type Code string
+
+const (
+	// CodeBadRequest is a request that cannot be read: a malformed body,
+	// path or query parameter
+	CodeBadRequest Code = "bad_request"
+	// CodeValidation is a request that was read but holds invalid values,
+	// listed by field in the "fields" detail when they are known
+	CodeValidation Code = "validation_failed"
+	// CodeUnauthorized is a missing or invalid credential
+	CodeUnauthorized Code = "unauthorized"
+	// CodeForbidden is a caller without the permission for a request
+	CodeForbidden Code = "forbidden"
+	// CodeNotFound is a record, or a route, that does not exist
+	CodeNotFound Code = "not_found"
+	// CodeMethodNotAllowed is a route that does not take the method
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	// CodeConflict is a request clashing with the current state, e.g. a
+	// duplicate or an already closed shift
+	CodeConflict Code = "conflict"
+	// CodeInsufficientStock is a sale or reservation of more than is in stock
+	CodeInsufficientStock Code = "insufficient_stock"
+	// CodeInsufficientPoints is redeeming more loyalty points than held
+	CodeInsufficientPoints Code = "insufficient_points"
+	// CodeCreditLimit is a sale on credit or repayment beyond what the
+	// customer's account allows
+	CodeCreditLimit Code = "credit_limit_exceeded"
+	// CodeVoucherNotApplicable is a voucher that cannot be used on a sale
+	CodeVoucherNotApplicable Code = "voucher_not_applicable"
+	// CodeTooManyAttempts is a credential locked after repeated failures
+	CodeTooManyAttempts Code = "too_many_attempts"
+	// CodeInternal is anything unexpected; its message says nothing more
+	CodeInternal Code = "internal_error"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:           http.StatusBadRequest,
+	CodeValidation:           http.StatusUnprocessableEntity,
+	CodeUnauthorized:         http.StatusUnauthorized,
+	CodeForbidden:            http.StatusForbidden,
+	CodeNotFound:             http.StatusNotFound,
+	CodeMethodNotAllowed:     http.StatusMethodNotAllowed,
+	CodeConflict:             http.StatusConflict,
+	CodeInsufficientStock:    http.StatusConflict,
+	CodeInsufficientPoints:   http.StatusConflict,
+	CodeCreditLimit:          http.StatusConflict,
+	CodeVoucherNotApplicable: http.StatusUnprocessableEntity,
+	CodeTooManyAttempts:      http.StatusTooManyRequests,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+// Status is the HTTP status a code is answered with
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error with a code. Details carry values a client may need,
+// such as the stock still available. Message is in English; key and args
+// translate it.
+type Error struct {
+	Code    Code           `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+
+	key  string
+	args []any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// With adds a detail to e and returns it
+func (e *Error) With(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = map[string]any{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Localize returns e with its message, and those of its field errors, in
+// lang. e itself is left alone, it may be shared.
+func (e *Error) Localize(lang i18n.Lang) *Error {
+	localized := *e
+	if e.key != "" {
+		localized.Message = i18n.T(lang, e.key, e.args...)
+	}
+	fields, ok := e.Details["fields"].([]FieldError)
+	if !ok {
+		return &localized
+	}
+	translated := make([]FieldError, len(fields))
+	for i, f := range fields {
+		translated[i] = f.localize(lang)
+	}
+	localized.Details = make(map[string]any, len(e.Details))
+	for k, v := range e.Details {
+		localized.Details[k] = v
+	}
+	localized.Details["fields"] = translated
+	if e.key == "" {
+		localized.Message = fieldsMessage(lang, translated)
+	}
+	return &localized
+}
+
+// New returns an error with a code and the message for an i18n key,
+// formatted with args
+func New(code Code, key string, args ...any) *Error {
+	return &Error{Code: code, Message: i18n.T(i18n.English, key, args...), key: key, args: args}
+}
+
+// BadRequest returns a CodeBadRequest error
+func BadRequest(key string, args ...any) *Error { return New(CodeBadRequest, key, args...) }
+
+// Validation returns a CodeValidation error
+func Validation(key string, args ...any) *Error { return New(CodeValidation, key, args...) }
+
+// Unauthorized returns a CodeUnauthorized error
+func Unauthorized(key string, args ...any) *Error { return New(CodeUnauthorized, key, args...) }
+
+// Forbidden returns a CodeForbidden error
+func Forbidden(key string, args ...any) *Error { return New(CodeForbidden, key, args...) }
+
+// NotFound returns a CodeNotFound error
+func NotFound(key string, args ...any) *Error { return New(CodeNotFound, key, args...) }
+
+// Conflict returns a CodeConflict error
+func Conflict(key string, args ...any) *Error { return New(CodeConflict, key, args...) }
+
+// InsufficientStock returns the error for selling or reserving requested
+// of a product when only available are left
+func InsufficientStock(productID int, name string, available, requested int) *Error {
+	return New(CodeInsufficientStock, "stock.insufficient", name, max(0, available)).
+		With("product_id", productID).
+		With("available", max(0, available)).
+		With("requested", requested)
+}
+
+// FieldError is what is wrong with one field of a request. Field is the
+// JSON path of the field, e.g. items[2].quantity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	key  string
+	args []any
+}
+
+// NewFieldError returns the error on field with the message for an i18n
+// key, formatted with args
+func NewFieldError(field, code, key string, args ...any) FieldError {
+	return FieldError{Field: field, Code: code, Message: i18n.T(i18n.English, key, args...), key: key, args: args}
+}
+
+func (f FieldError) localize(lang i18n.Lang) FieldError {
+	if f.key != "" {
+		f.Message = i18n.T(lang, f.key, f.args...)
+	}
+	return f
+}
+
+// Fields returns a CodeValidation error listing every field error. Its
+// message is that of the first field, counting the others.
+func Fields(fields []FieldError) *Error {
+	e := &Error{Code: CodeValidation, Message: fieldsMessage(i18n.English, fields)}
+	return e.With("fields", fields)
+}
+
+func fieldsMessage(lang i18n.Lang, fields []FieldError) string {
+	message := fields[0].Message
+	if len(fields) > 1 {
+		message += " " + i18n.T(lang, "validation.more", len(fields)-1)
+	}
+	return message
+}
+
+// As returns the *Error in err's chain, or a CodeInternal error with a
+// message that does not leak the cause when there is none
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return New(CodeInternal, "internal_error"), false
+}
+
+// Write answers a request with err in the error envelope, in the language
+// of the response's Content-Language header (English when it has none).
+// Errors that are not an *Error are answered as internal errors; logging
+// their cause is up to the caller.
+func Write(w http.ResponseWriter, err error) {
+	e, _ := As(err)
+	h := w.Header()
+	lang, ok := i18n.Parse(h.Get("Content-Language"))
+	if !ok {
+		lang = i18n.English
+	}
+	e = e.Localize(lang)
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Code.Status())
+	json.NewEncoder(w).Encode(map[string]*Error{"error": e})
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"strings"
+	"task-crud-kategori/apperror"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the shortest password accepted for an account
+const MinPasswordLength = 8
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", apperror.Validation("password.too_short", MinPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches a hash from HashPassword
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyHash is compared against when a username does not exist, so a
+// failed login takes as long whether or not the account exists
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// CheckNoPassword burns the time of a CheckPassword and always fails
+func CheckNoPassword(password string) bool {
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	return false
+}
+
+// ValidPIN reports whether pin is 4 to 8 digits
+func ValidPIN(pin string) bool {
+	return len(pin) >= 4 && len(pin) <= 8 && strings.Trim(pin, "0123456789") == ""
+}
+
+// HashPIN returns the bcrypt hash of a supervisor PIN of 4 to 8 digits
+func HashPIN(pin string) (string, error) {
+	if !ValidPIN(pin) {
+		return "", apperror.Validation("pin.invalid")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"errors"
+	"time"
+)
+
+// DBLayout is how timestamps are stored in SQLite. Timestamps are always
+// written in UTC, the same format SQLite's CURRENT_TIMESTAMP produces, so
+// stored values compare correctly as strings.
+const DBLayout = "2006-01-02 15:04:05"
+
+// DateLayout is the format of business dates in requests and responses
+const DateLayout = "2006-01-02"
+
+// DefaultTimezone is used when no store timezone is configured
+const DefaultTimezone = "Asia/Jakarta"
+
+// Calendar maps instants to the store's business days.
+//
+// A business day starts at CutoffHour in the store timezone and lasts until
+// the cut-off hour of the next day, so a shop closing at 02:00 can use a
+// cut-off of 3 and keep its late-night sales on the previous day.
+type Calendar struct {
+	Location   *time.Location
+	CutoffHour int
+}
+
+// NewCalendar loads the timezone by IANA name, e.g. "Asia/Jakarta"
+func NewCalendar(timezone string, cutoffHour int) (*Calendar, error) {
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+	if cutoffHour < 0 || cutoffHour > 23 {
+		return nil, errors.New("business day cut-off hour must be between 0 and 23")
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Calendar{Location: loc, CutoffHour: cutoffHour}, nil
+}
+
+// Now returns the current time in the store timezone
+func (c *Calendar) Now() time.Time {
+	return time.Now().In(c.Location)
+}
+
+// Today returns the current business date
+func (c *Calendar) Today() time.Time {
+	return c.DateOf(time.Now())
+}
+
+// DateOf returns the business date an instant belongs to, as midnight in
+// the store timezone
+func (c *Calendar) DateOf(t time.Time) time.Time {
+	local := t.In(c.Location).Add(-time.Duration(c.CutoffHour) * time.Hour)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location)
+}
+
+// ParseDate parses a YYYY-MM-DD business date in the store timezone
+func (c *Calendar) ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, value, c.Location)
+}
+
+// Bounds returns the instants [start, end) covered by the business days
+// from first to last inclusive
+func (c *Calendar) Bounds(first, last time.Time) (time.Time, time.Time) {
+	start := time.Date(first.Year(), first.Month(), first.Day(), c.CutoffHour, 0, 0, 0, c.Location)
+	end := time.Date(last.Year(), last.Month(), last.Day()+1, c.CutoffHour, 0, 0, 0, c.Location)
+	return start, end
+}
+
+// DaysBetween counts the calendar days from business date from to to.
+// Dates are compared by year, month and day, so a day lengthened or
+// shortened by daylight saving still counts as one.
+func DaysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// ToDB formats an instant for storage and comparison in SQLite
+func ToDB(t time.Time) string {
+	return t.UTC().Format(DBLayout)
+}
@@ -0,0 +1,44 @@
+package clock
+
+import (
+	"testing"
+	_ "time/tzdata" // the New York rules, also where the system has none
+)
+
+func TestDaysBetween(t *testing.T) {
+	// New York moves to summer time on 8 March 2026 and back on 1 November
+	calendar, err := NewCalendar("America/New_York", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     int
+	}{
+		{"same day", "2026-03-08", "2026-03-08", 0},
+		{"over the 23 hour day", "2026-03-08", "2026-03-09", 1},
+		{"week into summer time", "2026-03-05", "2026-03-12", 7},
+		{"over the 25 hour day", "2026-11-01", "2026-11-02", 1},
+		{"week out of summer time", "2026-10-29", "2026-11-05", 7},
+		{"backwards", "2026-03-09", "2026-03-08", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := calendar.ParseDate(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := calendar.ParseDate(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DaysBetween(from, to); got != tt.want {
+				t.Errorf("DaysBetween(%s, %s) = %d, want %d (elapsed %v)", tt.from, tt.to, got, tt.want, to.Sub(from))
+			}
+		})
+	}
+
+}
@@ -20,6 +20,48 @@ func Migrate(db *sql.DB) error {
 	if err := migrationReport(db); err != nil {
 		return err
 	}
+	if err := migrationShift(db); err != nil {
+		return err
+	}
+	if err := migrationRollup(db); err != nil {
+		return err
+	}
+	if err := migrationInventory(db); err != nil {
+		return err
+	}
+	if err := migrationCustomer(db); err != nil {
+		return err
+	}
+	if err := migrationLoyalty(db); err != nil {
+		return err
+	}
+	if err := migrationVoucher(db); err != nil {
+		return err
+	}
+	if err := migrationCredit(db); err != nil {
+		return err
+	}
+	if err := migrationCart(db); err != nil {
+		return err
+	}
+	if err := migrationAuth(db); err != nil {
+		return err
+	}
+	if err := migrationRBAC(db); err != nil {
+		return err
+	}
+	if err := migrationAPIKeys(db); err != nil {
+		return err
+	}
+	if err := migrationAudit(db); err != nil {
+		return err
+	}
+	if err := migrationEmployees(db); err != nil {
+		return err
+	}
+	if err := migrationPINLockout(db); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -74,6 +116,22 @@ func migrateProducts(db *sql.DB) error {
 		}
 	}
 
+	// 4. add created_at column to tables created before it existed
+	// (SQLite cannot add a column with a CURRENT_TIMESTAMP default)
+	hasCreatedAt, err := columnExists(db, "products", "created_at")
+	if err != nil {
+		return err
+	}
+	if !hasCreatedAt {
+		alter := `
+		ALTER TABLE products
+		ADD COLUMN created_at DATETIME;
+		`
+		if _, err := db.Exec(alter); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -182,6 +240,563 @@ func migrationReport(db *sql.DB) error {
 	return err
 }
 
+// =======================
+// MIGRATE SHIFTS
+// =======================
+// payment method, discount and shift on transactions, refunds, shifts and
+// the immutable, sequentially numbered z_reports
+func migrationShift(db *sql.DB) error {
+	return applyMigration(db, "002_shift", `
+	ALTER TABLE transactions ADD COLUMN payment_method TEXT NOT NULL DEFAULT 'cash';
+	ALTER TABLE transactions ADD COLUMN discount_amount INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE transactions ADD COLUMN shift_id INTEGER REFERENCES shifts(id);
+
+	CREATE TABLE IF NOT EXISTS shifts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		status TEXT NOT NULL DEFAULT 'open',
+		opened_by TEXT NOT NULL DEFAULT '',
+		opening_float INTEGER NOT NULL DEFAULT 0,
+		opened_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		closed_by TEXT NOT NULL DEFAULT '',
+		closed_at DATETIME,
+		counted_cash INTEGER,
+		z_number INTEGER UNIQUE,
+		note TEXT NOT NULL DEFAULT ''
+	);
+
+	-- only one register session can be open at a time
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_one_open
+		ON shifts(status) WHERE status = 'open';
+
+	CREATE TABLE IF NOT EXISTS refunds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		transaction_id INTEGER NOT NULL UNIQUE,
+		amount INTEGER NOT NULL,
+		payment_method TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		shift_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+		FOREIGN KEY (shift_id) REFERENCES shifts(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS z_reports (
+		z_number INTEGER PRIMARY KEY,
+		shift_id INTEGER NOT NULL UNIQUE,
+		report TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (shift_id) REFERENCES shifts(id)
+	);
+
+	CREATE TRIGGER IF NOT EXISTS z_reports_no_update
+	BEFORE UPDATE ON z_reports
+	BEGIN
+		SELECT RAISE(ABORT, 'z report is immutable');
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS z_reports_no_delete
+	BEFORE DELETE ON z_reports
+	BEGIN
+		SELECT RAISE(ABORT, 'z report is immutable');
+	END;
+
+	CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions(shift_id);
+	`)
+}
+
+// =======================
+// MIGRATE SALES ROLLUPS
+// =======================
+// daily pre-aggregated sales, maintained at checkout/refund time.
+// rollup_state records the calendar the rollups were built with; until it
+// matches the running configuration reports read the raw transactions.
+func migrationRollup(db *sql.DB) error {
+	return applyMigration(db, "003_rollup", `
+	CREATE TABLE IF NOT EXISTS sales_daily (
+		business_date TEXT PRIMARY KEY,
+		transactions INTEGER NOT NULL DEFAULT 0,
+		revenue INTEGER NOT NULL DEFAULT 0,
+		gross_sales INTEGER NOT NULL DEFAULT 0,
+		discounts INTEGER NOT NULL DEFAULT 0,
+		items_sold INTEGER NOT NULL DEFAULT 0,
+		refund_count INTEGER NOT NULL DEFAULT 0,
+		refunds INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS sales_daily_product (
+		business_date TEXT NOT NULL,
+		product_id INTEGER NOT NULL,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		revenue INTEGER NOT NULL DEFAULT 0,
+		refunded_quantity INTEGER NOT NULL DEFAULT 0,
+		refunds INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (business_date, product_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS sales_daily_category (
+		business_date TEXT NOT NULL,
+		category_id INTEGER NOT NULL,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		revenue INTEGER NOT NULL DEFAULT 0,
+		refunded_quantity INTEGER NOT NULL DEFAULT 0,
+		refunds INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (business_date, category_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS sales_daily_payment (
+		business_date TEXT NOT NULL,
+		payment_method TEXT NOT NULL,
+		transactions INTEGER NOT NULL DEFAULT 0,
+		sales INTEGER NOT NULL DEFAULT 0,
+		refunds INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (business_date, payment_method)
+	);
+
+	CREATE TABLE IF NOT EXISTS rollup_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		timezone TEXT NOT NULL,
+		cutoff_hour INTEGER NOT NULL,
+		built_at DATETIME NOT NULL
+	);
+	`)
+}
+
+// =======================
+// MIGRATE INVENTORY
+// =======================
+// cost price on products and the stock movement ledger. Existing sales and
+// refunds are backfilled; their unit cost/price is unknown and left NULL.
+func migrationInventory(db *sql.DB) error {
+	return applyMigration(db, "004_inventory", `
+	ALTER TABLE products ADD COLUMN cost INTEGER NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		quantity INTEGER NOT NULL,
+		stock_after INTEGER,
+		unit_cost INTEGER,
+		unit_price INTEGER,
+		reference TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_product
+		ON stock_movements(product_id, created_at);
+
+	INSERT INTO stock_movements (product_id, type, quantity, reference, created_at)
+	SELECT td.product_id, 'sale', -td.quantity, 'transaction:' || td.transaction_id, t.created_at
+	FROM transaction_details td
+	JOIN transactions t ON t.id = td.transaction_id;
+
+	INSERT INTO stock_movements (product_id, type, quantity, reference, created_at)
+	SELECT td.product_id, 'refund', td.quantity, 'refund:' || r.id, r.created_at
+	FROM refunds r
+	JOIN transaction_details td ON td.transaction_id = r.transaction_id;
+	`)
+}
+
+// =======================
+// MIGRATE CUSTOMERS
+// =======================
+// customer records and the optional customer on a transaction. A phone
+// number identifies one customer; customers without one are allowed.
+func migrationCustomer(db *sql.DB) error {
+	return applyMigration(db, "005_customer", `
+	CREATE TABLE IF NOT EXISTS customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		phone TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		notes TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone
+		ON customers(phone) WHERE phone <> '';
+
+	ALTER TABLE transactions ADD COLUMN customer_id INTEGER REFERENCES customers(id);
+
+	CREATE INDEX IF NOT EXISTS idx_transactions_customer
+		ON transactions(customer_id, created_at);
+	`)
+}
+
+// =======================
+// MIGRATE LOYALTY
+// =======================
+// the loyalty program and its points ledger. Earned points are lots with a
+// remaining balance and an expiry; redemptions record the lots they drew
+// from so a refund can put the points back where they came from.
+// Category 0 in loyalty_rules is the rule for every other category.
+func migrationLoyalty(db *sql.DB) error {
+	return applyMigration(db, "006_loyalty", `
+	CREATE TABLE IF NOT EXISTS loyalty_settings (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		point_value INTEGER NOT NULL,
+		expiry_days INTEGER NOT NULL
+	);
+
+	INSERT INTO loyalty_settings (id, point_value, expiry_days) VALUES (1, 10, 365);
+
+	CREATE TABLE IF NOT EXISTS loyalty_rules (
+		category_id INTEGER PRIMARY KEY,
+		rupiah_per_point INTEGER NOT NULL
+	);
+
+	INSERT INTO loyalty_rules (category_id, rupiah_per_point) VALUES (0, 1000);
+
+	CREATE TABLE IF NOT EXISTS loyalty_tiers (
+		name TEXT PRIMARY KEY,
+		min_points INTEGER NOT NULL,
+		discount_percent REAL NOT NULL
+	);
+
+	INSERT INTO loyalty_tiers (name, min_points, discount_percent) VALUES
+		('silver', 1000, 2),
+		('gold', 5000, 5);
+
+	CREATE TABLE IF NOT EXISTS loyalty_ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL REFERENCES customers(id),
+		type TEXT NOT NULL,
+		points INTEGER NOT NULL,
+		remaining INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME,
+		transaction_id INTEGER,
+		reference TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_customer
+		ON loyalty_ledger(customer_id, id);
+	CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_transaction
+		ON loyalty_ledger(transaction_id);
+
+	CREATE TABLE IF NOT EXISTS loyalty_redemptions (
+		redeem_id INTEGER NOT NULL REFERENCES loyalty_ledger(id),
+		lot_id INTEGER NOT NULL REFERENCES loyalty_ledger(id),
+		points INTEGER NOT NULL,
+		PRIMARY KEY (redeem_id, lot_id)
+	);
+	`)
+}
+
+// =======================
+// MIGRATE VOUCHERS
+// =======================
+// coupon codes and their redemptions. times_used is only changed with a
+// conditional update inside the checkout, which is what keeps a code from
+// being redeemed more often than usage_limit allows. Validity dates are
+// business dates; 0 limits mean unlimited.
+func migrationVoucher(db *sql.DB) error {
+	return applyMigration(db, "007_voucher", `
+	CREATE TABLE IF NOT EXISTS vouchers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		code TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL,
+		value INTEGER NOT NULL,
+		max_discount INTEGER NOT NULL DEFAULT 0,
+		min_spend INTEGER NOT NULL DEFAULT 0,
+		valid_from TEXT,
+		valid_until TEXT,
+		usage_limit INTEGER NOT NULL DEFAULT 0,
+		per_customer_limit INTEGER NOT NULL DEFAULT 0,
+		times_used INTEGER NOT NULL DEFAULT 0,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS voucher_products (
+		voucher_id INTEGER NOT NULL REFERENCES vouchers(id),
+		product_id INTEGER NOT NULL,
+		PRIMARY KEY (voucher_id, product_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS voucher_categories (
+		voucher_id INTEGER NOT NULL REFERENCES vouchers(id),
+		category_id INTEGER NOT NULL,
+		PRIMARY KEY (voucher_id, category_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS voucher_redemptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		voucher_id INTEGER NOT NULL REFERENCES vouchers(id),
+		transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+		customer_id INTEGER,
+		amount INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reversed_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_voucher_redemptions_voucher
+		ON voucher_redemptions(voucher_id, customer_id);
+	`)
+}
+
+// =======================
+// MIGRATE CUSTOMER CREDIT
+// =======================
+// kasbon: sales paid with the credit method are owed by the customer until
+// repaid. A credit limit of 0 means the customer cannot buy on credit.
+func migrationCredit(db *sql.DB) error {
+	return applyMigration(db, "008_credit", `
+	ALTER TABLE customers ADD COLUMN credit_limit INTEGER NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS customer_repayments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL REFERENCES customers(id),
+		amount INTEGER NOT NULL,
+		payment_method TEXT NOT NULL,
+		shift_id INTEGER REFERENCES shifts(id),
+		note TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_customer_repayments_customer
+		ON customer_repayments(customer_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_customer_repayments_shift
+		ON customer_repayments(shift_id);
+	`)
+}
+
+// =======================
+// MIGRATE CARTS
+// =======================
+// parked baskets. Lines of an open, unexpired cart with reserve set hold
+// their quantity back from other sales until the cart expires.
+func migrationCart(db *sql.DB) error {
+	return applyMigration(db, "009_cart", `
+	CREATE TABLE IF NOT EXISTS carts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		label TEXT NOT NULL DEFAULT '',
+		customer_id INTEGER REFERENCES customers(id),
+		reserve INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'open',
+		ttl_minutes INTEGER NOT NULL,
+		transaction_id INTEGER REFERENCES transactions(id),
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS cart_items (
+		cart_id INTEGER NOT NULL REFERENCES carts(id),
+		product_id INTEGER NOT NULL REFERENCES products(id),
+		quantity INTEGER NOT NULL,
+		PRIMARY KEY (cart_id, product_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_carts_status
+		ON carts(status, expires_at);
+	CREATE INDEX IF NOT EXISTS idx_cart_items_product
+		ON cart_items(product_id);
+	`)
+}
+
+// =======================
+// MIGRATE USERS & SESSIONS
+// =======================
+// a session is one login; its refresh token is stored hashed and changes
+// on every refresh. Logging out revokes the session and every access
+// token issued for it.
+func migrationAuth(db *sql.DB) error {
+	return applyMigration(db, "010_auth", `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		name TEXT NOT NULL DEFAULT '',
+		password_hash TEXT NOT NULL,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS user_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		refresh_hash TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL,
+		revoked_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_user_sessions_user
+		ON user_sessions(user_id);
+	`)
+}
+
+// =======================
+// MIGRATE ROLES
+// =======================
+// users made before roles existed could do everything, so they become
+// owners. Sales and refunds remember who rang them up and who approved
+// a supervisor override.
+func migrationRBAC(db *sql.DB) error {
+	return applyMigration(db, "011_rbac", `
+	ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'cashier';
+	ALTER TABLE users ADD COLUMN pin_hash TEXT NOT NULL DEFAULT '';
+	UPDATE users SET role = 'owner';
+
+	ALTER TABLE transactions ADD COLUMN user_id INTEGER REFERENCES users(id);
+	ALTER TABLE transactions ADD COLUMN approved_by INTEGER REFERENCES users(id);
+	ALTER TABLE refunds ADD COLUMN user_id INTEGER REFERENCES users(id);
+	ALTER TABLE refunds ADD COLUMN approved_by INTEGER REFERENCES users(id);
+
+	CREATE INDEX IF NOT EXISTS idx_transactions_user
+		ON transactions(user_id);
+	`)
+}
+
+// =======================
+// MIGRATE API KEYS
+// =======================
+// keys for scripts and devices that cannot sign in. Only the hash of a key
+// is kept; prefix is its first characters, to tell keys apart in lists.
+func migrationAPIKeys(db *sql.DB) error {
+	return applyMigration(db, "012_api_keys", `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		created_by INTEGER REFERENCES users(id),
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS api_key_scopes (
+		api_key_id INTEGER NOT NULL REFERENCES api_keys(id),
+		permission TEXT NOT NULL,
+		PRIMARY KEY (api_key_id, permission)
+	);
+	`)
+}
+
+// =======================
+// MIGRATE AUDIT LOG
+// =======================
+// one row per data-changing call. actor_id is a user id or, with
+// actor_type 'api_key', an API key id; actor_name keeps the name in case
+// either is deleted later. changes holds the changed fields as
+// {"field": {"before": ..., "after": ...}}.
+func migrationAudit(db *sql.DB) error {
+	return applyMigration(db, "013_audit", `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor_type TEXT NOT NULL,
+		actor_id INTEGER,
+		actor_name TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		changes TEXT NOT NULL DEFAULT '{}',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+	`)
+}
+
+// =======================
+// MIGRATE EMPLOYEES
+// =======================
+// staff profiles, linked to the user account a cashier signs in with when
+// they have one, and their clock-in/clock-out records. An employee can
+// only be clocked in once at a time.
+func migrationEmployees(db *sql.DB) error {
+	return applyMigration(db, "014_employees", `
+	CREATE TABLE IF NOT EXISTS employees (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER UNIQUE REFERENCES users(id),
+		name TEXT NOT NULL,
+		phone TEXT NOT NULL DEFAULT '',
+		position TEXT NOT NULL DEFAULT '',
+		hired_on TEXT NOT NULL DEFAULT '',
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS time_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		employee_id INTEGER NOT NULL REFERENCES employees(id),
+		clock_in DATETIME NOT NULL,
+		clock_out DATETIME,
+		note TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_one_open
+		ON time_entries(employee_id) WHERE clock_out IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_time_entries_clock_in ON time_entries(employee_id, clock_in);
+	CREATE INDEX IF NOT EXISTS idx_refunds_user_id ON refunds(user_id);
+	`)
+}
+
+// =======================
+// MIGRATE PIN LOCKOUT
+// =======================
+// failed supervisor PIN entries since the last good one; too many lock
+// the PIN until pin_locked_until
+func migrationPINLockout(db *sql.DB) error {
+	return applyMigration(db, "015_pin_lockout", `
+	ALTER TABLE users ADD COLUMN pin_failures INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE users ADD COLUMN pin_locked_until DATETIME;
+	`)
+}
+
+// =======================
+// APPLY VERSIONED MIGRATION
+// =======================
+// applyMigration runs migrationSQL once, inside a transaction, and records
+// version in schema_migrations
+func applyMigration(db *sql.DB, version, migrationSQL string) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = db.QueryRow(
+		`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`,
+		version,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil // sudah di-migrate
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migrationSQL); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // =======================
 // CHECK COLUMN EXISTS
 // =======================
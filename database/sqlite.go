@@ -3,13 +3,24 @@ package database
 import (
 	"database/sql"
 	"log"
+	"strings"
 
 	_ "modernc.org/sqlite" // using modernc.org/sqlite driver for SQLite becouse it's pure Go implementation and cross-platform no need CGO
 )
 
 func InitDB(dbPath string) (*sql.DB, error) {
+	// Transactions take the write lock when they begin, so concurrent
+	// checkouts queue up (for up to 5s) instead of failing half-way with
+	// SQLITE_BUSY, and checks like a voucher's usage limit see every
+	// earlier redemption
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+	dsn := dbPath + separator + "_txlock=immediate&_pragma=busy_timeout(5000)"
+
 	// Open database connection
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn)
 	// Handle error
 	if err != nil {
 		return nil, err
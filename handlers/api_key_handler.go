@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// APIKeyHandler handles HTTP requests for API keys
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// GetAll - GET /api/api-keys
+func (h *APIKeyHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// Create - POST /api/api-keys
+// The response holds the key itself; it cannot be shown again.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	key, err := h.service.Create(req, user)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// GetByID - GET /api/api-keys/{id}
+func (h *APIKeyHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "apikey.invalid_id")
+	if !ok {
+		return
+	}
+
+	key, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+// Revoke - DELETE /api/api-keys/{id}
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "apikey.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "apikey.revoked"),
+	})
+}
+
+// Rotate - POST /api/api-keys/{id}/rotate
+// Returns the new key; the old one stops working.
+func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "apikey.invalid_id")
+	if !ok {
+		return
+	}
+
+	key, err := h.service.Rotate(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
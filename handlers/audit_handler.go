@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// AuditHandler handles HTTP requests for the audit log
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAll - GET /api/audit
+// Filters: entity_type, entity_id, actor_type, actor_id, action,
+// request_id, start_date, end_date (business dates) and limit
+func (h *AuditHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		ActorType:  query.Get("actor_type"),
+		Action:     query.Get("action"),
+		RequestID:  query.Get("request_id"),
+	}
+
+	var ok bool
+	if filter.EntityID, ok = parsePositiveInt(w, query, "entity_id", 0); !ok {
+		return
+	}
+	if filter.ActorID, ok = parsePositiveInt(w, query, "actor_id", 0); !ok {
+		return
+	}
+	if filter.Limit, ok = parsePositiveInt(w, query, "limit", services.DefaultAuditLimit); !ok {
+		return
+	}
+	if filter.StartDate, ok = parseOptionalDate(w, query, "start_date", h.service.ParseDate); !ok {
+		return
+	}
+	if filter.EndDate, ok = parseOptionalDate(w, query, "end_date", h.service.ParseDate); !ok {
+		return
+	}
+
+	entries, err := h.service.GetAll(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
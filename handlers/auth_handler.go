@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/auth"
+	"task-crud-kategori/middleware"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// AuthHandler handles sign-in and guards the API
+type AuthHandler struct {
+	service       *services.AuthService
+	apiKeyService *services.APIKeyService
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(service *services.AuthService, apiKeyService *services.APIKeyService) *AuthHandler {
+	return &AuthHandler{service: service, apiKeyService: apiKeyService}
+}
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	sessionKey
+	approverKey
+	apiKeyKey
+)
+
+// CurrentUser returns the signed in user of a request that went through
+// RequireAuth
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userKey).(*models.User)
+	return user
+}
+
+// CurrentAPIKey returns the API key a request was made with, or nil when
+// a user signed in
+func CurrentAPIKey(r *http.Request) *models.APIKey {
+	key, _ := r.Context().Value(apiKeyKey).(*models.APIKey)
+	return key
+}
+
+// currentUserID returns the id of the signed in user, or nil for requests
+// made with an API key
+func currentUserID(r *http.Request) *int {
+	if user := CurrentUser(r); user != nil {
+		return &user.ID
+	}
+	return nil
+}
+
+// requireUser returns the signed in user, writing a 403 for requests made
+// with an API key
+func requireUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user := CurrentUser(r)
+	if user == nil {
+		apperror.Write(w, apperror.Forbidden("auth.user_required"))
+		return nil, false
+	}
+	return user, true
+}
+
+// auditActor is who the audit log records for a request
+func auditActor(r *http.Request) models.Actor {
+	actor := models.Actor{RequestID: middleware.GetRequestID(r)}
+	if user := CurrentUser(r); user != nil {
+		actor.UserID, actor.Name = &user.ID, user.Username
+	} else if key := CurrentAPIKey(r); key != nil {
+		actor.APIKeyID, actor.Name = &key.ID, key.Name
+	}
+	return actor
+}
+
+// currentSession returns the session id of a request that went through
+// RequireAuth
+func currentSession(r *http.Request) int {
+	id, _ := r.Context().Value(sessionKey).(int)
+	return id
+}
+
+// Approver returns the supervisor who approved an override for this
+// request, or nil when the user had the permission themselves
+func Approver(r *http.Request) *models.User {
+	approver, _ := r.Context().Value(approverKey).(*models.User)
+	return approver
+}
+
+// publicPaths can be called without signing in
+var publicPaths = map[string]bool{
+	"/health":           true,
+	"/api/auth/login":   true,
+	"/api/auth/refresh": true,
+}
+
+// RequireAuth lets requests to /api/* through only with a valid
+// "Authorization: Bearer <access token>" header, or an API key sent either
+// in the X-API-Key header or as the bearer token.
+func (h *AuthHandler) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		token = strings.TrimSpace(token)
+		if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+			token, ok = apiKey, true
+		}
+		if !ok || token == "" {
+			unauthorized(w, r, apperror.Unauthorized("auth.missing_token"))
+			return
+		}
+
+		if strings.HasPrefix(token, auth.APIKeyPrefix) {
+			key, err := h.apiKeyService.Authenticate(token)
+			if err != nil {
+				unauthorized(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey, key)))
+			return
+		}
+
+		user, sessionID, err := h.service.Authenticate(token)
+		if err != nil {
+			unauthorized(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userKey, user)
+		ctx = context.WithValue(ctx, sessionKey, sessionID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require only lets users holding permission call next. Where the
+// permission allows it a supervisor can approve the request instead by
+// sending their username and PIN in the X-Supervisor and X-Supervisor-Pin
+// headers.
+func (h *AuthHandler) Require(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approver, ok := authorize(w, r, h.service, permission)
+		if !ok {
+			return
+		}
+		if approver != nil {
+			r = r.WithContext(context.WithValue(r.Context(), approverKey, approver))
+		}
+		next(w, r)
+	}
+}
+
+// authorize checks the signed in user holds permission, or a supervisor
+// approved it. An API key must have permission among its scopes; it
+// cannot be approved. It writes a 403 and returns false when none is true.
+func authorize(w http.ResponseWriter, r *http.Request, service *services.AuthService, permission string) (*models.User, bool) {
+	if key := CurrentAPIKey(r); key != nil {
+		if key.HasScope(permission) {
+			return nil, true
+		}
+		apperror.Write(w, apperror.Forbidden("auth.scope_missing", permission))
+		return nil, false
+	}
+
+	user := CurrentUser(r)
+	if user != nil && models.HasPermission(user.Role, permission) {
+		return nil, true
+	}
+
+	if supervisor := r.Header.Get("X-Supervisor"); supervisor != "" && models.IsOverridable(permission) {
+		approver, err := service.Approve(supervisor, r.Header.Get("X-Supervisor-Pin"), permission)
+		if err != nil {
+			writeError(w, r, err)
+			return nil, false
+		}
+		return approver, true
+	}
+
+	key := "auth.permission_denied"
+	if models.IsOverridable(permission) {
+		key = "auth.permission_denied_overridable"
+	}
+	apperror.Write(w, apperror.Forbidden(key, permission))
+	return nil, false
+}
+
+// unauthorized rejects the credentials of a request, telling the client
+// why when err says so
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := apperror.As(err)
+	if !ok {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	rejected := *e
+	rejected.Code = apperror.CodeUnauthorized
+	apperror.Write(w, &rejected)
+}
+
+// Login - POST /api/auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	tokens, err := h.service.Login(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Refresh - POST /api/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	tokens, err := h.service.Refresh(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout - POST /api/auth/logout
+// Ends the session of the access token used to call it
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireUser(w, r); !ok {
+		return
+	}
+
+	if err := h.service.Logout(currentSession(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "auth.logged_out"),
+	})
+}
+
+// Me - GET /api/auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	current, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	user := *current
+	user.Permissions = models.PermissionsOf(user.Role)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// CartHandler handles HTTP requests for parked carts
+type CartHandler struct {
+	service     *services.CartService
+	authService *services.AuthService
+}
+
+// NewCartHandler creates a new CartHandler
+func NewCartHandler(service *services.CartService, authService *services.AuthService) *CartHandler {
+	return &CartHandler{service: service, authService: authService}
+}
+
+// GetOpen - GET /api/carts
+func (h *CartHandler) GetOpen(w http.ResponseWriter, r *http.Request) {
+	carts, err := h.service.GetOpen()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(carts)
+}
+
+// Create - POST /api/carts
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	cart, err := h.service.Create(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cart)
+}
+
+// GetByID - GET /api/carts/{id}
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+// Update - PUT /api/carts/{id}
+// Fields missing from the body keep their current value.
+func (h *CartHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(cart); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+	cart.ID = id
+
+	if err := h.service.Update(cart); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.GetByID(w, r)
+}
+
+// Cancel - DELETE /api/carts/{id}
+func (h *CartHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Cancel(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "cart.cancelled"),
+	})
+}
+
+// AddItem - POST /api/carts/{id}/items
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return
+	}
+
+	var item models.CheckoutItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	cart, err := h.service.AddItem(id, item)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+// SetItem - PUT /api/carts/{id}/items/{product_id}
+func (h *CartHandler) SetItem(w http.ResponseWriter, r *http.Request) {
+	id, productID, ok := cartItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	var item models.CheckoutItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	cart, err := h.service.SetItem(id, productID, item.Quantity)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+// RemoveItem - DELETE /api/carts/{id}/items/{product_id}
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, productID, ok := cartItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.RemoveItem(id, productID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+// Checkout - POST /api/carts/{id}/checkout
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return
+	}
+
+	var req models.CartCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	// a manual discount needs the same approval as at the till
+	approvedBy, ok := authorizePriceOverride(w, r, h.authService, req.Discount > 0)
+	if !ok {
+		return
+	}
+	req.UserID, req.ApprovedBy = currentUserID(r), approvedBy
+
+	transaction, err := h.service.Checkout(id, req, auditActor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// cartItemPath reads the cart and product ids of /api/carts/{id}/items/{product_id}
+func cartItemPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	id, ok := pathID(w, r, "id", "cart.invalid_id")
+	if !ok {
+		return 0, 0, false
+	}
+	productID, ok := pathID(w, r, "product_id", "product.invalid_id")
+	if !ok {
+		return 0, 0, false
+	}
+	return id, productID, true
+}
+
+// Expire - POST /api/carts/expire
+// Closes the carts nobody touched within their time to live
+func (h *CartHandler) Expire(w http.ResponseWriter, r *http.Request) {
+	expired, err := h.service.ExpireAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"expired": expired})
+}
@@ -3,8 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 	"task-crud-kategori/models"
 	"task-crud-kategori/services"
 )
@@ -19,26 +17,13 @@ func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
 	return &CategoryHandler{service: service}
 }
 
-// HandleCategories - GET /api/categories
-func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
-	// Route based on HTTP method
-	switch r.Method {
-	case http.MethodGet:
-		h.GetAll(w, r) // GET ALL CATEGORIES
-	case http.MethodPost:
-		h.Create(w, r) // CREATE CATEGORY
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
 // GetAll - GET /api/categories
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	// Get all categories
 	categories, err := h.service.GetAll()
 	// Handle error
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	// Respond with categories
@@ -52,13 +37,13 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		badRequest(w, "request.invalid_body")
 		return
 	}
 	// Call service to create category
-	err = h.service.Create(&category)
+	err = h.service.Create(&category, auditActor(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 	// Respond with created category
@@ -67,37 +52,18 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
-// HandleCategoryByID - GET/PUT/DELETE /api/categories/{id}
-func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
-	// Route based on HTTP method
-	switch r.Method {
-	case http.MethodGet:
-		h.GetByID(w, r) // GET CATEGORY BY ID
-	case http.MethodPut:
-		h.Update(w, r) // UPDATE CATEGORY BY ID
-	case http.MethodDelete:
-		h.Delete(w, r) // DELETE CATEGORY BY ID
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
 // GetByID - GET /api/categories/{id}
 func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from URL
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
-	//	Convert to integer
-	id, err := strconv.Atoi(idStr)
-	// Handle conversion error
-	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+	// Read ID from the path
+	id, ok := pathID(w, r, "id", "category.invalid_id")
+	if !ok {
 		return
 	}
 	// Call service to get category by ID
 	category, err := h.service.GetByID(id)
 	// Handle service error
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 	// Respond with category
@@ -105,30 +71,27 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
+// Update - PUT /api/categories/{id}
 func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from URL
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
-	//	Convert to integer
-	id, err := strconv.Atoi(idStr)
-	// Handle conversion error
-	if err != nil {
-		http.Error(w, "Invalid Category ID", http.StatusBadRequest)
+	// Read ID from the path
+	id, ok := pathID(w, r, "id", "category.invalid_id")
+	if !ok {
 		return
 	}
 
 	// Decode request body
 	var category models.Category
-	err = json.NewDecoder(r.Body).Decode(&category)
+	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		badRequest(w, "request.invalid_body")
 		return
 	}
 	// Set the ID from URL
 	category.ID = id
 	// Call service to update category
-	err = h.service.Update(&category)
+	err = h.service.Update(&category, auditActor(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 	// Respond with updated category
@@ -138,26 +101,22 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete - DELETE /api/categories/{id}
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from URL
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
-	//	Convert to integer
-	id, err := strconv.Atoi(idStr)
-	// Handle conversion error
-	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+	// Read ID from the path
+	id, ok := pathID(w, r, "id", "category.invalid_id")
+	if !ok {
 		return
 	}
 	// Call service to delete category
-	err = h.service.Delete(id)
+	err := h.service.Delete(id, auditActor(r))
 	// Handle service error
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	// Respond with success message
 	w.Header().Set("Content-Type", "application/json")
 	// Return a success message
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Category deleted successfully",
+		"message": localize(r, "category.deleted"),
 	})
 }
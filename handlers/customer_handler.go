@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// CustomerHandler handles HTTP requests for customers
+type CustomerHandler struct {
+	service        *services.CustomerService
+	loyaltyService *services.LoyaltyService
+	creditService  *services.CreditService
+	authService    *services.AuthService
+}
+
+// NewCustomerHandler creates a new CustomerHandler
+func NewCustomerHandler(
+	service *services.CustomerService,
+	loyaltyService *services.LoyaltyService,
+	creditService *services.CreditService,
+	authService *services.AuthService,
+) *CustomerHandler {
+	return &CustomerHandler{
+		service:        service,
+		loyaltyService: loyaltyService,
+		creditService:  creditService,
+		authService:    authService,
+	}
+}
+
+// GetAll - GET /api/customers?q=
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAll(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customers)
+}
+
+// Create - POST /api/customers
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	// a credit limit lets the customer buy on credit, only managers grant it
+	if customer.CreditLimit != 0 {
+		if _, ok := authorize(w, r, h.authService, models.PermCreditManage); !ok {
+			return
+		}
+	}
+
+	if err := h.service.Create(&customer); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(customer)
+}
+
+// GetByID - GET /api/customers/{id}
+func (h *CustomerHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// Update - PUT /api/customers/{id}
+// Fields missing from the body keep their current value.
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit := customer.CreditLimit
+	if err := json.NewDecoder(r.Body).Decode(customer); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+	customer.ID = id
+
+	if customer.CreditLimit != limit {
+		if _, ok := authorize(w, r, h.authService, models.PermCreditManage); !ok {
+			return
+		}
+	}
+
+	if err := h.service.Update(customer); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// Delete - DELETE /api/customers/{id}
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "customer.deleted"),
+	})
+}
+
+// GetHistory - GET /api/customers/{id}/transactions
+func (h *CustomerHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetHistory(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetLifetimeValue - GET /api/customers/{id}/lifetime-value
+func (h *CustomerHandler) GetLifetimeValue(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	value, err := h.service.GetLifetimeValue(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// GetLoyalty - GET /api/customers/{id}/loyalty
+func (h *CustomerHandler) GetLoyalty(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	account, err := h.loyaltyService.GetAccount(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// GetLoyaltyLedger - GET /api/customers/{id}/loyalty/ledger
+func (h *CustomerHandler) GetLoyaltyLedger(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	entries, err := h.loyaltyService.GetLedger(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetCredit - GET /api/customers/{id}/credit
+func (h *CustomerHandler) GetCredit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	account, err := h.creditService.GetAccount(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// Repay - POST /api/customers/{id}/repayments
+func (h *CustomerHandler) Repay(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "customer.invalid_id")
+	if !ok {
+		return
+	}
+
+	var req models.RepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	repayment, err := h.creditService.Repay(id, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repayment)
+}
+
+// GetReceivablesAging - GET /api/report/receivables-aging
+func (h *CustomerHandler) GetReceivablesAging(w http.ResponseWriter, r *http.Request) {
+	report, err := h.creditService.GetAging()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetTopCustomers - GET /api/report/customers
+// Query: limit (default 20)
+func (h *CustomerHandler) GetTopCustomers(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parsePositiveInt(w, r.URL.Query(), "limit", 20)
+	if !ok {
+		return
+	}
+
+	values, err := h.service.GetTopCustomers(limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(values)
+}
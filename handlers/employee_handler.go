@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// EmployeeHandler handles HTTP requests for employees and the time clock
+type EmployeeHandler struct {
+	service *services.EmployeeService
+}
+
+// NewEmployeeHandler creates a new EmployeeHandler
+func NewEmployeeHandler(service *services.EmployeeService) *EmployeeHandler {
+	return &EmployeeHandler{service: service}
+}
+
+// GetAll - GET /api/employees?include_inactive=true
+func (h *EmployeeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	employees, err := h.service.GetAll(r.URL.Query().Get("include_inactive") == "true")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(employees)
+}
+
+// Create - POST /api/employees
+func (h *EmployeeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.EmployeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	employee, err := h.service.Create(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(employee)
+}
+
+// GetByID - GET /api/employees/{id}
+func (h *EmployeeHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	employee, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(employee)
+}
+
+// Update - PUT /api/employees/{id}
+// Empty fields keep their current value.
+func (h *EmployeeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	var req models.EmployeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	employee, err := h.service.Update(id, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(employee)
+}
+
+// Delete - DELETE /api/employees/{id}
+func (h *EmployeeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "employee.deleted"),
+	})
+}
+
+// ClockIn - POST /api/employees/{id}/clock-in
+// Clocks an employee in on their behalf, e.g. when they forgot
+func (h *EmployeeHandler) ClockIn(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	h.clockIn(w, r, id)
+}
+
+// ClockOut - POST /api/employees/{id}/clock-out
+func (h *EmployeeHandler) ClockOut(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	h.clockOut(w, r, id)
+}
+
+// GetTimesheet - GET /api/employees/{id}/time-entries?start_date=&end_date=
+// Defaults to the last 7 days.
+func (h *EmployeeHandler) GetTimesheet(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "employee.invalid_id")
+	if !ok {
+		return
+	}
+
+	start, end, ok := parseDateRange(w, r.URL.Query(), h.service, 7)
+	if !ok {
+		return
+	}
+
+	sheet, err := h.service.GetTimesheet(id, start, end)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sheet)
+}
+
+// GetTimeClock - GET /api/time-clock
+// The employee profile of the signed in user, with their open time entry
+func (h *EmployeeHandler) GetTimeClock(w http.ResponseWriter, r *http.Request) {
+	employee, ok := h.currentEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(employee)
+}
+
+// ClockInSelf - POST /api/time-clock/clock-in
+func (h *EmployeeHandler) ClockInSelf(w http.ResponseWriter, r *http.Request) {
+	employee, ok := h.currentEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	h.clockIn(w, r, employee.ID)
+}
+
+// ClockOutSelf - POST /api/time-clock/clock-out
+func (h *EmployeeHandler) ClockOutSelf(w http.ResponseWriter, r *http.Request) {
+	employee, ok := h.currentEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	h.clockOut(w, r, employee.ID)
+}
+
+func (h *EmployeeHandler) clockIn(w http.ResponseWriter, r *http.Request, id int) {
+	req, ok := decodeClockRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.ClockIn(id, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *EmployeeHandler) clockOut(w http.ResponseWriter, r *http.Request, id int) {
+	req, ok := decodeClockRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.ClockOut(id, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// currentEmployee returns the employee linked to the signed in user
+func (h *EmployeeHandler) currentEmployee(w http.ResponseWriter, r *http.Request) (*models.Employee, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	employee, err := h.service.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return employee, true
+}
+
+// decodeClockRequest reads the optional body of a clock-in or clock-out
+func decodeClockRequest(w http.ResponseWriter, r *http.Request) (models.ClockRequest, bool) {
+	var req models.ClockRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			badRequest(w, "request.invalid_body")
+			return req, false
+		}
+	}
+	return req, true
+}
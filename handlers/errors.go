@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/i18n"
+	"task-crud-kategori/middleware"
+)
+
+// writeError answers with err in the JSON error envelope, its status
+// taken from the error's code. Unexpected errors are logged with the
+// request id; the client only sees an internal error.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if _, ok := apperror.As(err); !ok {
+		slog.ErrorContext(r.Context(), "request failed",
+			slog.String("request_id", middleware.GetRequestID(r)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("error", err.Error()),
+		)
+	}
+	apperror.Write(w, err)
+}
+
+// badRequest answers 400 for a request whose body, path or query
+// parameters cannot be read, with the message for an i18n key
+func badRequest(w http.ResponseWriter, key string, args ...any) {
+	apperror.Write(w, apperror.BadRequest(key, args...))
+}
+
+// localize returns the message for an i18n key in the language of r
+func localize(r *http.Request, key string, args ...any) string {
+	return i18n.T(i18n.FromContext(r.Context()), key, args...)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"task-crud-kategori/analytics"
+	"task-crud-kategori/services"
+)
+
+// ForecastHandler exposes the demand forecasts
+type ForecastHandler struct {
+	service *services.ForecastService
+}
+
+// NewForecastHandler creates a new ForecastHandler
+func NewForecastHandler(service *services.ForecastService) *ForecastHandler {
+	return &ForecastHandler{service: service}
+}
+
+// GetForecast - GET /api/forecast
+// Query: product_id (default: all products), days (default 14), history
+// (default 56), method (moving_average or seasonal, default seasonal)
+func (h *ForecastHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	productID := 0
+	if v := query.Get("product_id"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "product_id")
+			return
+		}
+		productID = parsed
+	}
+
+	days, ok := parsePositiveInt(w, query, "days", 14)
+	if !ok {
+		return
+	}
+	history, ok := parsePositiveInt(w, query, "history", 56)
+	if !ok {
+		return
+	}
+
+	method := query.Get("method")
+	if method == "" {
+		method = analytics.MethodSeasonal
+	}
+	if !services.IsValidForecastMethod(method) {
+		badRequest(w, "request.invalid_param", "method")
+		return
+	}
+
+	result, err := h.service.Forecast(productID, days, history, method)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetBacktest - GET /api/forecast/backtest
+// Query: history (default 84), holdout (default 14)
+func (h *ForecastHandler) GetBacktest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	history, ok := parsePositiveInt(w, query, "history", 84)
+	if !ok {
+		return
+	}
+	holdout, ok := parsePositiveInt(w, query, "holdout", 14)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Backtest(history, holdout)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
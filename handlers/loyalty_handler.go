@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// LoyaltyHandler exposes the loyalty program configuration
+type LoyaltyHandler struct {
+	service *services.LoyaltyService
+}
+
+// NewLoyaltyHandler creates a new LoyaltyHandler
+func NewLoyaltyHandler(service *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+// GetProgram - GET /api/loyalty/program
+func (h *LoyaltyHandler) GetProgram(w http.ResponseWriter, r *http.Request) {
+	program, err := h.service.GetProgram()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+// UpdateProgram - PUT /api/loyalty/program
+func (h *LoyaltyHandler) UpdateProgram(w http.ResponseWriter, r *http.Request) {
+	var program models.LoyaltyProgram
+	if err := json.NewDecoder(r.Body).Decode(&program); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	if err := h.service.UpdateProgram(&program); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+// Expire - POST /api/loyalty/expire
+func (h *LoyaltyHandler) Expire(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.ExpireAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
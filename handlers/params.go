@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// pathID reads the integer path wildcard name of a route, e.g. {id}. It
+// writes the 400 itself, with the message for an i18n key.
+func pathID(w http.ResponseWriter, r *http.Request, name, key string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		badRequest(w, key)
+		return 0, false
+	}
+	return id, true
+}
@@ -3,37 +3,25 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 	"task-crud-kategori/models"
 	"task-crud-kategori/services"
 )
 
 type ProductHandler struct {
-	service *services.ProductService
+	service       *services.ProductService
+	reportService *services.ReportService
 }
 
-func NewProductHandler(service *services.ProductService) *ProductHandler {
-	return &ProductHandler{service: service}
-}
-
-// HandleProducts - GET /api/produk
-func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.GetAll(w, r)
-	case http.MethodPost:
-		h.Create(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
+func NewProductHandler(service *services.ProductService, reportService *services.ReportService) *ProductHandler {
+	return &ProductHandler{service: service, reportService: reportService}
 }
 
+// GetAll - GET /api/produk
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	products, err := h.service.GetAll(name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -41,17 +29,18 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(products)
 }
 
+// Create - POST /api/produk
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var product models.Product
 	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		badRequest(w, "request.invalid_body")
 		return
 	}
 
-	err = h.service.Create(&product)
+	err = h.service.Create(&product, auditActor(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -60,32 +49,16 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
-// HandleProductByID - GET/PUT/DELETE /api/produk/{id}
-func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.GetByID(w, r)
-	case http.MethodPut:
-		h.Update(w, r)
-	case http.MethodDelete:
-		h.Delete(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
 // GetByID - GET /api/produk/{id}
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/produk/")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+	id, ok := pathID(w, r, "id", "product.invalid_id")
+	if !ok {
 		return
 	}
 
 	product, err := h.service.GetByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
@@ -93,25 +66,24 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
+// Update - PUT /api/produk/{id}
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/produk/")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+	id, ok := pathID(w, r, "id", "product.invalid_id")
+	if !ok {
 		return
 	}
 
 	var product models.Product
-	err = json.NewDecoder(r.Body).Decode(&product)
+	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		badRequest(w, "request.invalid_body")
 		return
 	}
 
 	product.ID = id
-	err = h.service.Update(&product)
+	err = h.service.Update(&product, auditActor(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -121,21 +93,92 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete - DELETE /api/produk/{id}
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/produk/")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+	id, ok := pathID(w, r, "id", "product.invalid_id")
+	if !ok {
 		return
 	}
 
-	err = h.service.Delete(id)
+	err := h.service.Delete(id, auditActor(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Product deleted successfully",
+		"message": localize(r, "product.deleted"),
 	})
 }
+
+// GetMovements - GET /api/produk/{id}/movements
+func (h *ProductHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "product.invalid_id")
+	if !ok {
+		return
+	}
+
+	movements, err := h.service.GetMovements(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// GetBoughtTogether - GET /api/produk/{id}/bought-together
+func (h *ProductHandler) GetBoughtTogether(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "product.invalid_id")
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	start, end, ok := parseDateRange(w, query, h.reportService, 90)
+	if !ok {
+		return
+	}
+
+	minCount, ok := parsePositiveInt(w, query, "min_count", 1)
+	if !ok {
+		return
+	}
+	limit, ok := parsePositiveInt(w, query, "limit", 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.reportService.GetBoughtTogether(id, start, end, minCount, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result.Name = product.Name
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetByCategory - GET /api/categories/{id}/products
+func (h *ProductHandler) GetByCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "category.invalid_id")
+	if !ok {
+		return
+	}
+
+	products, err := h.service.GetByCategory(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
@@ -3,7 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
+	"task-crud-kategori/apperror"
+	"time"
 
+	"task-crud-kategori/models"
 	"task-crud-kategori/services"
 )
 
@@ -17,16 +22,419 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 
 // GET /api/report/hari-ini
 // GET /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+//
+// Optional breakdowns:
+// top=N, bottom=N       product rankings
+// rank_by=quantity|revenue
+// by_category=true      sales grouped per category
+// field_names=en        English names: total_transactions, best_product
 func (h *ReportHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
-	startDate := r.URL.Query().Get("start_date")
-	endDate := r.URL.Query().Get("end_date")
+	query := r.URL.Query()
 
-	result, err := h.service.GetSummary(startDate, endDate)
+	// both dates are required for a range, otherwise today
+	var startDate, endDate time.Time
+	if query.Get("start_date") != "" && query.Get("end_date") != "" {
+		var err error
+		startDate, err = h.service.ParseDate(query.Get("start_date"))
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return
+		}
+		endDate, err = h.service.ParseDate(query.Get("end_date"))
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return
+		}
+		if endDate.Before(startDate) {
+			apperror.Write(w, apperror.Validation("field.not_before", "end_date", "start_date"))
+			return
+		}
+	}
+
+	opts := models.ReportOptions{RankBy: services.RankByQuantity}
+	var err error
+
+	if v := query.Get("top"); v != "" {
+		opts.Top, err = strconv.Atoi(v)
+		if err != nil || opts.Top < 0 {
+			badRequest(w, "request.invalid_param", "top")
+			return
+		}
+	}
+	if v := query.Get("bottom"); v != "" {
+		opts.Bottom, err = strconv.Atoi(v)
+		if err != nil || opts.Bottom < 0 {
+			badRequest(w, "request.invalid_param", "bottom")
+			return
+		}
+	}
+	if v := query.Get("rank_by"); v != "" {
+		if !services.IsValidRankBy(v) {
+			badRequest(w, "request.invalid_param", "rank_by")
+			return
+		}
+		opts.RankBy = v
+	}
+	if v := query.Get("by_category"); v != "" {
+		opts.ByCategory, err = strconv.ParseBool(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "by_category")
+			return
+		}
+	}
+	fieldNames := query.Get("field_names")
+	if fieldNames != "" && fieldNames != "id" && fieldNames != "en" {
+		badRequest(w, "request.invalid_param", "field_names")
+		return
+	}
+
+	result, err := h.service.GetSummary(startDate, endDate, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if fieldNames == "en" {
+		json.NewEncoder(w).Encode(result.English())
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET /api/report/timeseries?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&interval=day|week|month&compare=true
+func (h *ReportHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// default: the last 30 business days, per day
+	end := h.service.Today()
+	if v := query.Get("end_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -29)
+	if v := query.Get("start_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return
+		}
+		start = parsed
+	}
+
+	if end.Before(start) {
+		apperror.Write(w, apperror.Validation("field.not_before", "end_date", "start_date"))
+		return
+	}
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = services.IntervalDay
+	}
+	if !services.IsValidInterval(interval) {
+		badRequest(w, "request.invalid_param", "interval")
+		return
+	}
+
+	compare := false
+	if v := query.Get("compare"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "compare")
+			return
+		}
+		compare = parsed
+	}
+
+	result, err := h.service.GetTimeSeries(start, end, interval, compare)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET /api/report/heatmap?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&peaks=5
+func (h *ReportHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// default: the last 4 weeks, so every weekday counts equally
+	end := h.service.Today()
+	if v := query.Get("end_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -27)
+	if v := query.Get("start_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return
+		}
+		start = parsed
+	}
+
+	if end.Before(start) {
+		apperror.Write(w, apperror.Validation("field.not_before", "end_date", "start_date"))
+		return
+	}
+
+	peaks := 5
+	if v := query.Get("peaks"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			badRequest(w, "request.invalid_param", "peaks")
+			return
+		}
+		peaks = parsed
+	}
+
+	result, err := h.service.GetHeatmap(start, end, peaks)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET /api/report/abc?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&by=revenue|quantity&a=80&b=95
+func (h *ReportHandler) GetABC(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// default: the last 90 business days by revenue
+	end := h.service.Today()
+	if v := query.Get("end_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -89)
+	if v := query.Get("start_date"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return
+		}
+		start = parsed
+	}
+
+	by := query.Get("by")
+	if by == "" {
+		by = services.RankByRevenue
+	}
+	if !services.IsValidRankBy(by) {
+		badRequest(w, "request.invalid_param", "by")
+		return
+	}
+
+	thresholdA, thresholdB := 80.0, 95.0
+	if v := query.Get("a"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "a")
+			return
+		}
+		thresholdA = parsed
+	}
+	if v := query.Get("b"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "b")
+			return
+		}
+		thresholdB = parsed
+	}
+
+	result, err := h.service.GetABC(start, end, by, thresholdA, thresholdB)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET /api/report/dead-stock?days=90&slow_threshold=0
+func (h *ReportHandler) GetDeadStock(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	days := 90
+	if v := query.Get("days"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			badRequest(w, "request.invalid_param", "days")
+			return
+		}
+		days = parsed
+	}
+
+	slowThreshold := 0
+	if v := query.Get("slow_threshold"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			badRequest(w, "request.invalid_param", "slow_threshold")
+			return
+		}
+		slowThreshold = parsed
+	}
+
+	result, err := h.service.GetDeadStock(days, slowThreshold)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET /api/report/inventory-valuation?as_of=YYYY-MM-DD
+func (h *ReportHandler) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	asOf := h.service.Today()
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		parsed, err := h.service.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "as_of")
+			return
+		}
+		asOf = parsed
+	}
+
+	result, err := h.service.GetInventoryValuation(asOf)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetBasketAnalysis - GET /api/report/basket
+// Query: start_date, end_date (default: the last 90 days), min_count
+// (default 2), limit (default 20)
+func (h *ReportHandler) GetBasketAnalysis(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, end, ok := parseDateRange(w, query, h.service, 90)
+	if !ok {
+		return
+	}
+
+	minCount, ok := parsePositiveInt(w, query, "min_count", 2)
+	if !ok {
+		return
+	}
+	limit, ok := parsePositiveInt(w, query, "limit", 20)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetBasketAnalysis(start, end, minCount, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
+
+// GetCashiers - GET /api/report/cashiers
+// Query: start_date, end_date (default: the last 7 days)
+func (h *ReportHandler) GetCashiers(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := parseDateRange(w, r.URL.Query(), h.service, 7)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetCashierReport(start, end)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// businessCalendar is a service that knows the store's business days
+type businessCalendar interface {
+	Today() time.Time
+	ParseDate(value string) (time.Time, error)
+}
+
+// parseDateRange reads start_date and end_date; by default the range is
+// the given number of days ending today. It writes the 400 itself.
+func parseDateRange(w http.ResponseWriter, query url.Values, calendar businessCalendar, days int) (time.Time, time.Time, bool) {
+	end := calendar.Today()
+	if v := query.Get("end_date"); v != "" {
+		parsed, err := calendar.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return time.Time{}, time.Time{}, false
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(days - 1))
+	if v := query.Get("start_date"); v != "" {
+		parsed, err := calendar.ParseDate(v)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return time.Time{}, time.Time{}, false
+		}
+		start = parsed
+	}
+
+	return start, end, true
+}
+
+// parseOptionalDate reads an optional date query parameter with parse; the
+// zero time means it was not given
+func parseOptionalDate(w http.ResponseWriter, query url.Values, name string, parse func(string) (time.Time, error)) (time.Time, bool) {
+	v := query.Get(name)
+	if v == "" {
+		return time.Time{}, true
+	}
+
+	parsed, err := parse(v)
+	if err != nil {
+		badRequest(w, "request.invalid_param", name)
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// parsePositiveInt reads an optional positive integer query parameter
+func parsePositiveInt(w http.ResponseWriter, query url.Values, name string, def int) (int, bool) {
+	v := query.Get(name)
+	if v == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		badRequest(w, "request.invalid_param", name)
+		return 0, false
+	}
+	return n, true
+}
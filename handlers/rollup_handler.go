@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-crud-kategori/services"
+)
+
+// RollupHandler exposes the rollup maintenance tools
+type RollupHandler struct {
+	service *services.RollupService
+}
+
+// NewRollupHandler creates a new RollupHandler
+func NewRollupHandler(service *services.RollupService) *RollupHandler {
+	return &RollupHandler{service: service}
+}
+
+// POST /api/report/rollups/rebuild
+func (h *RollupHandler) Rebuild(w http.ResponseWriter, r *http.Request) {
+	days, err := h.service.Rebuild()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": localize(r, "rollup.rebuilt"),
+		"days":    days,
+	})
+}
+
+// GET /api/report/rollups/check
+func (h *RollupHandler) Check(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Check()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
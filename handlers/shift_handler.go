@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// ShiftHandler handles HTTP requests for register shifts and X/Z reports
+type ShiftHandler struct {
+	service *services.ShiftService
+}
+
+// NewShiftHandler creates a new ShiftHandler
+func NewShiftHandler(service *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+// GetAll - GET /api/shifts
+func (h *ShiftHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shifts)
+}
+
+// Open - POST /api/shifts
+func (h *ShiftHandler) Open(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+	req.OpenedBy = user.Username
+
+	shift, err := h.service.Open(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+// GetCurrent - GET /api/shifts/current
+func (h *ShiftHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.service.GetCurrent()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// GetByID - GET /api/shifts/{id}
+func (h *ShiftHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "shift.invalid_id")
+	if !ok {
+		return
+	}
+
+	shift, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// XReport - GET /api/shifts/{id}/x-report
+func (h *ShiftHandler) XReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "shift.invalid_id")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.XReport(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Close - POST /api/shifts/{id}/close
+func (h *ShiftHandler) Close(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(w, r, "id", "shift.invalid_id")
+	if !ok {
+		return
+	}
+
+	var req models.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+	req.ClosedBy = user.Username
+
+	report, err := h.service.Close(id, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetZReports - GET /api/z-reports
+func (h *ShiftHandler) GetZReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.service.GetZReports()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// GetZReport - GET /api/z-reports/{number}
+func (h *ShiftHandler) GetZReport(w http.ResponseWriter, r *http.Request) {
+	number, ok := pathID(w, r, "number", "zreport.invalid_number")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetZReport(number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -3,52 +3,163 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"task-crud-kategori/apperror"
+	"time"
 
 	"task-crud-kategori/models"
 	"task-crud-kategori/services"
 )
 
 type TransactionHandler struct {
-	service *services.TransactionService
+	service     *services.TransactionService
+	authService *services.AuthService
 }
 
-func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+func NewTransactionHandler(service *services.TransactionService, authService *services.AuthService) *TransactionHandler {
+	return &TransactionHandler{service: service, authService: authService}
 }
 
 // =======================
-// HANDLE CHECKOUT
+// CHECKOUT
 // POST /api/checkout
 // =======================
-func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var req models.CheckoutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
 		return
 	}
 
-	h.Checkout(w, r)
+	overridden := req.Discount > 0
+	for _, item := range req.Items {
+		overridden = overridden || item.Price != nil
+	}
+	approvedBy, ok := authorizePriceOverride(w, r, h.authService, overridden)
+	if !ok {
+		return
+	}
+	req.UserID, req.ApprovedBy = currentUserID(r), approvedBy
+
+	transaction, err := h.service.Checkout(req, auditActor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// authorizePriceOverride checks a sale that changes a price or takes a
+// manual discount: it needs the price:override permission or a
+// supervisor's approval. It returns the approving supervisor's id, if any,
+// and writes the 403 itself.
+func authorizePriceOverride(w http.ResponseWriter, r *http.Request, service *services.AuthService, overridden bool) (*int, bool) {
+	if !overridden {
+		return nil, true
+	}
+	approver, ok := authorize(w, r, service, models.PermPriceOverride)
+	if !ok || approver == nil {
+		return nil, ok
+	}
+	return &approver.ID, true
 }
 
-func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
-	var req models.CheckoutRequest
+// =======================
+// GET TRANSACTIONS
+// GET /api/transactions?date=YYYY-MM-DD
+// GET /api/transactions?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+// =======================
+func (h *TransactionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startStr, endStr := query.Get("start_date"), query.Get("end_date")
+	if date := query.Get("date"); date != "" {
+		startStr, endStr = date, date
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// business dates, empty means today
+	var startDate, endDate time.Time
+	if startStr != "" && endStr != "" {
+		var err error
+		startDate, err = h.service.ParseDate(startStr)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "start_date")
+			return
+		}
+		endDate, err = h.service.ParseDate(endStr)
+		if err != nil {
+			badRequest(w, "request.invalid_param", "end_date")
+			return
+		}
+		if endDate.Before(startDate) {
+			apperror.Write(w, apperror.Validation("field.not_before", "end_date", "start_date"))
+			return
+		}
+	}
+
+	transactions, err := h.service.GetAll(startDate, endDate)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if len(req.Items) == 0 {
-		http.Error(w, "Items cannot be empty", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// =======================
+// GET TRANSACTION BY ID
+// GET /api/transactions/{id}
+// =======================
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "transaction.invalid_id")
+	if !ok {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	transaction, err := h.service.GetByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(transaction)
 }
+
+// =======================
+// REFUND TRANSACTION
+// POST /api/transactions/{id}/refund
+// =======================
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "transaction.invalid_id")
+	if !ok {
+		return
+	}
+
+	// the body is optional, it only carries the reason
+	var req models.RefundRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			badRequest(w, "request.invalid_body")
+			return
+		}
+	}
+
+	req.UserID = currentUserID(r)
+	if approver := Approver(r); approver != nil {
+		req.ApprovedBy = &approver.ID
+	}
+
+	refund, err := h.service.Refund(id, req, auditActor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// UserHandler handles HTTP requests for staff accounts
+type UserHandler struct {
+	service *services.UserService
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(service *services.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// GetAll - GET /api/users
+func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// Create - POST /api/users
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	user, err := h.service.Create(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetByID - GET /api/users/{id}
+func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "user.invalid_id")
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// Update - PUT /api/users/{id}
+// Fields missing from the body keep their current value; a password
+// resets it and signs the user out everywhere.
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "user.invalid_id")
+	if !ok {
+		return
+	}
+
+	var req models.UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	user, err := h.service.Update(id, req, CurrentUser(r).ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// Delete - DELETE /api/users/{id}
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "user.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, CurrentUser(r).ID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "user.deleted"),
+	})
+}
+
+// ChangePassword - PUT /api/users/me/password
+// Other sessions of the user are signed out; this one stays signed in.
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PasswordChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	if err := h.service.ChangePassword(user.ID, currentSession(r), req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "user.password_changed"),
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-crud-kategori/models"
+	"task-crud-kategori/services"
+)
+
+// VoucherHandler handles HTTP requests for vouchers
+type VoucherHandler struct {
+	service *services.VoucherService
+}
+
+// NewVoucherHandler creates a new VoucherHandler
+func NewVoucherHandler(service *services.VoucherService) *VoucherHandler {
+	return &VoucherHandler{service: service}
+}
+
+// GetAll - GET /api/vouchers
+func (h *VoucherHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vouchers, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vouchers)
+}
+
+// Create - POST /api/vouchers
+func (h *VoucherHandler) Create(w http.ResponseWriter, r *http.Request) {
+	// new vouchers are active unless the body says otherwise
+	voucher := models.Voucher{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&voucher); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+
+	if err := h.service.Create(&voucher); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(voucher)
+}
+
+// GetByID - GET /api/vouchers/{id}
+func (h *VoucherHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "voucher.invalid_id")
+	if !ok {
+		return
+	}
+
+	voucher, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(voucher)
+}
+
+// Update - PUT /api/vouchers/{id}
+// Fields missing from the body keep their current value.
+func (h *VoucherHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "voucher.invalid_id")
+	if !ok {
+		return
+	}
+
+	voucher, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(voucher); err != nil {
+		badRequest(w, "request.invalid_body")
+		return
+	}
+	voucher.ID = id
+
+	if err := h.service.Update(voucher); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(voucher)
+}
+
+// Delete - DELETE /api/vouchers/{id}
+func (h *VoucherHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "voucher.invalid_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": localize(r, "voucher.deleted"),
+	})
+}
+
+// GetRedemptions - GET /api/vouchers/{id}/redemptions
+func (h *VoucherHandler) GetRedemptions(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "voucher.invalid_id")
+	if !ok {
+		return
+	}
+
+	redemptions, err := h.service.GetRedemptions(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redemptions)
+}
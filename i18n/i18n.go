@@ -0,0 +1,99 @@
+// Package i18n holds the catalogue of API messages in the languages the
+// API speaks, and picks the language of a request from its
+// Accept-Language header.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is a language the API answers in
+type Lang string
+
+const (
+	Indonesian Lang = "id"
+	English    Lang = "en"
+)
+
+// Parse returns the supported language of a tag such as "id", "en-US" or
+// "in" (the old code for Indonesian)
+func Parse(tag string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch base {
+	case "id", "in":
+		return Indonesian, true
+	case "en":
+		return English, true
+	}
+	return "", false
+}
+
+// Negotiate picks the supported language an Accept-Language header
+// prefers most, or fallback when it names none
+func Negotiate(header string, fallback Lang) Lang {
+	type weighted struct {
+		lang Lang
+		q    float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, weighted{lang, q})
+		}
+	}
+	if len(accepted) == 0 {
+		return fallback
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+	return accepted[0].lang
+}
+
+type contextKey int
+
+const langKey contextKey = iota
+
+// WithLang returns ctx carrying the language of a request
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, langKey, lang)
+}
+
+// FromContext returns the language of a request, English when none was
+// negotiated
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(langKey).(Lang); ok {
+		return lang
+	}
+	return English
+}
+
+// T returns the message for key in lang, formatted with args. Messages
+// missing in lang fall back to English, unknown keys to the key itself.
+func T(lang Lang, key string, args ...any) string {
+	translations := catalogue[key]
+	format, ok := translations[lang]
+	if !ok {
+		format, ok = translations[English]
+	}
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
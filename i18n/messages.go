@@ -0,0 +1,170 @@
+package i18n
+
+// catalogue maps a message key to its text per language. Keys named after
+// an error code are the generic message of that code; the others are
+// grouped by what they are about. Texts are fmt formats, and every
+// language takes the same arguments in the same order.
+var catalogue = map[string]map[Lang]string{
+	// error codes
+	"bad_request":            {English: "bad request", Indonesian: "permintaan tidak valid"},
+	"validation_failed":      {English: "validation failed", Indonesian: "validasi gagal"},
+	"unauthorized":           {English: "unauthorized", Indonesian: "tidak terautentikasi"},
+	"forbidden":              {English: "forbidden", Indonesian: "akses ditolak"},
+	"not_found":              {English: "not found", Indonesian: "tidak ditemukan"},
+	"method_not_allowed":     {English: "method not allowed", Indonesian: "metode tidak diizinkan"},
+	"conflict":               {English: "conflict", Indonesian: "konflik dengan data yang ada"},
+	"insufficient_stock":     {English: "stock not enough", Indonesian: "stok tidak cukup"},
+	"insufficient_points":    {English: "not enough points", Indonesian: "poin tidak cukup"},
+	"credit_limit_exceeded":  {English: "credit limit exceeded", Indonesian: "batas kredit terlampaui"},
+	"voucher_not_applicable": {English: "voucher cannot be used", Indonesian: "voucher tidak dapat digunakan"},
+	"too_many_attempts":      {English: "too many attempts", Indonesian: "terlalu banyak percobaan"},
+	"internal_error":         {English: "internal server error", Indonesian: "terjadi kesalahan pada server"},
+
+	// requests and routes
+	"request.invalid_body":  {English: "Invalid request body", Indonesian: "Isi permintaan tidak valid"},
+	"request.invalid_param": {English: "Invalid %s", Indonesian: "%s tidak valid"},
+	"route.not_found":       {English: "route not found", Indonesian: "rute tidak ditemukan"},
+
+	// fields
+	"field.required":     {English: "%s is required", Indonesian: "%s wajib diisi"},
+	"field.empty":        {English: "%s cannot be empty", Indonesian: "%s tidak boleh kosong"},
+	"field.negative":     {English: "%s cannot be negative", Indonesian: "%s tidak boleh negatif"},
+	"field.not_positive": {English: "%s must be positive", Indonesian: "%s harus lebih dari 0"},
+	"field.min":          {English: "%s must be at least %d", Indonesian: "%s minimal %d"},
+	"field.min_days":     {English: "%s must be at least %d days", Indonesian: "%s minimal %d hari"},
+	"field.out_of_range": {English: "%s must be between %v and %v", Indonesian: "%s harus antara %v dan %v"},
+	"field.one_of":       {English: "%s must be one of %s", Indonesian: "%s harus salah satu dari %s"},
+	"field.date":         {English: "%s must be a date, YYYY-MM-DD", Indonesian: "%s harus berupa tanggal, YYYY-MM-DD"},
+	"field.not_before":   {English: "%s must not be before %s", Indonesian: "%s tidak boleh sebelum %s"},
+	"field.future":       {English: "%s must be in the future", Indonesian: "%s harus di masa depan"},
+	"field.not_future":   {English: "%s cannot be in the future", Indonesian: "%s tidak boleh di masa depan"},
+	"field.too_short":    {English: "%s must be at least %d characters", Indonesian: "%s minimal %d karakter"},
+	"field.email":        {English: "invalid email address", Indonesian: "alamat email tidak valid"},
+	"validation.more":    {English: "(and %d more)", Indonesian: "(dan %d lainnya)"},
+
+	// sign in and permissions
+	"auth.missing_token":                 {English: "Missing bearer token", Indonesian: "Token bearer tidak ada"},
+	"auth.invalid_token":                 {English: "invalid or expired token", Indonesian: "token tidak valid atau kedaluwarsa"},
+	"auth.invalid_refresh_token":         {English: "invalid or expired refresh token", Indonesian: "refresh token tidak valid atau kedaluwarsa"},
+	"auth.invalid_api_key":               {English: "invalid, revoked or expired API key", Indonesian: "API key tidak valid, sudah dicabut, atau kedaluwarsa"},
+	"auth.bad_credentials":               {English: "invalid username or password", Indonesian: "username atau password salah"},
+	"auth.session_expired":               {English: "session expired", Indonesian: "sesi telah berakhir"},
+	"auth.user_required":                 {English: "This needs a signed in user, not an API key", Indonesian: "Permintaan ini membutuhkan pengguna yang masuk, bukan API key"},
+	"auth.scope_missing":                 {English: "API key lacks scope: %s", Indonesian: "API key tidak memiliki scope: %s"},
+	"auth.permission_denied":             {English: "Permission denied: %s", Indonesian: "Akses ditolak: %s"},
+	"auth.permission_denied_overridable": {English: "Permission denied: %s (a supervisor can approve it)", Indonesian: "Akses ditolak: %s (supervisor dapat menyetujuinya)"},
+	"auth.override_denied":               {English: "supervisor override not approved", Indonesian: "persetujuan supervisor ditolak"},
+	"auth.pin_locked":                    {English: "supervisor PIN is locked after too many wrong entries, try again later", Indonesian: "PIN supervisor dikunci karena terlalu banyak salah, coba lagi nanti"},
+	"auth.logged_out":                    {English: "Logged out successfully", Indonesian: "Berhasil keluar"},
+	"password.too_short":                 {English: "password must be at least %d characters", Indonesian: "password minimal %d karakter"},
+	"pin.invalid":                        {English: "pin must be 4 to 8 digits", Indonesian: "PIN harus 4 sampai 8 digit"},
+
+	// users and API keys
+	"user.invalid_id":             {English: "Invalid user ID", Indonesian: "ID user tidak valid"},
+	"user.not_found":              {English: "user not found", Indonesian: "user tidak ditemukan"},
+	"user.username_taken":         {English: "username already exists", Indonesian: "username sudah dipakai"},
+	"user.username_spaces":        {English: "username cannot contain spaces", Indonesian: "username tidak boleh mengandung spasi"},
+	"user.invalid_role":           {English: "role must be cashier, supervisor or owner", Indonesian: "role harus cashier, supervisor, atau owner"},
+	"user.wrong_password":         {English: "current password is wrong", Indonesian: "password saat ini salah"},
+	"user.has_transactions":       {English: "user has transactions, deactivate them instead", Indonesian: "user sudah memiliki transaksi, nonaktifkan saja"},
+	"user.own_role":               {English: "cannot change your own role", Indonesian: "tidak dapat mengubah role akun sendiri"},
+	"user.own_deactivate":         {English: "cannot deactivate your own account", Indonesian: "tidak dapat menonaktifkan akun sendiri"},
+	"user.own_delete":             {English: "cannot delete your own account", Indonesian: "tidak dapat menghapus akun sendiri"},
+	"user.deleted":                {English: "User deleted successfully", Indonesian: "User berhasil dihapus"},
+	"user.password_changed":       {English: "Password changed successfully", Indonesian: "Password berhasil diubah"},
+	"apikey.invalid_id":           {English: "Invalid API key ID", Indonesian: "ID API key tidak valid"},
+	"apikey.not_found":            {English: "API key not found", Indonesian: "API key tidak ditemukan"},
+	"apikey.not_found_or_revoked": {English: "API key not found or already revoked", Indonesian: "API key tidak ditemukan atau sudah dicabut"},
+	"apikey.scopes_required":      {English: "at least one scope is required", Indonesian: "minimal satu scope wajib diisi"},
+	"apikey.invalid_scope":        {English: "invalid scope %q", Indonesian: "scope %q tidak valid"},
+	"apikey.cannot_grant":         {English: "cannot grant %s, you do not have it", Indonesian: "tidak dapat memberikan %s karena Anda tidak memilikinya"},
+	"apikey.revoked":              {English: "API key revoked successfully", Indonesian: "API key berhasil dicabut"},
+
+	// products, categories and stock
+	"product.invalid_id":       {English: "Invalid product ID", Indonesian: "ID produk tidak valid"},
+	"product.not_found":        {English: "product not found", Indonesian: "produk tidak ditemukan"},
+	"product.id_not_found":     {English: "product id %d not found", Indonesian: "produk dengan id %d tidak ditemukan"},
+	"product.unknown_category": {English: "category_id is not an existing category", Indonesian: "category_id bukan kategori yang ada"},
+	"product.negative_price":   {English: "price for product %s cannot be negative", Indonesian: "harga produk %s tidak boleh negatif"},
+	"product.deleted":          {English: "Product deleted successfully", Indonesian: "Produk berhasil dihapus"},
+	"category.invalid_id":      {English: "Invalid category ID", Indonesian: "ID kategori tidak valid"},
+	"category.not_found":       {English: "category not found", Indonesian: "kategori tidak ditemukan"},
+	"category.has_products":    {English: "category still has %d products, move or delete them first", Indonesian: "kategori masih memiliki %d produk, pindahkan atau hapus produknya terlebih dahulu"},
+	"category.deleted":         {English: "Category deleted successfully", Indonesian: "Kategori berhasil dihapus"},
+	"stock.insufficient":       {English: "stock not enough for product %s: %d available", Indonesian: "stok produk %s tidak cukup: tersedia %d"},
+
+	// sales and carts
+	"transaction.invalid_id":          {English: "Invalid transaction ID", Indonesian: "ID transaksi tidak valid"},
+	"transaction.not_found":           {English: "transaction not found", Indonesian: "transaksi tidak ditemukan"},
+	"transaction.already_refunded":    {English: "transaction already refunded", Indonesian: "transaksi sudah direfund"},
+	"checkout.invalid_payment_method": {English: "invalid payment method", Indonesian: "metode pembayaran tidak valid"},
+	"checkout.duplicate_item":         {English: "product %d is already on %s[%d], put it on one line", Indonesian: "produk %d sudah ada di %s[%d], gabungkan dalam satu baris"},
+	"checkout.discount_exceeds_total": {English: "discount cannot exceed the transaction total", Indonesian: "diskon tidak boleh melebihi total transaksi"},
+	"checkout.points_need_customer":   {English: "redeeming points needs a customer", Indonesian: "penukaran poin membutuhkan pelanggan"},
+	"checkout.credit_needs_customer":  {English: "paying later needs a customer", Indonesian: "bayar nanti membutuhkan pelanggan"},
+	"cart.invalid_id":                 {English: "Invalid cart ID", Indonesian: "ID cart tidak valid"},
+	"cart.not_found":                  {English: "cart not found", Indonesian: "cart tidak ditemukan"},
+	"cart.item_not_found":             {English: "product is not in the cart", Indonesian: "produk tidak ada di cart"},
+	"cart.empty":                      {English: "cart is empty", Indonesian: "cart kosong"},
+	"cart.not_open":                   {English: "cart is %s", Indonesian: "cart berstatus %s"},
+	"cart.cancelled":                  {English: "Cart cancelled successfully", Indonesian: "Cart berhasil dibatalkan"},
+
+	// customers, loyalty and credit
+	"customer.invalid_id":        {English: "Invalid customer ID", Indonesian: "ID pelanggan tidak valid"},
+	"customer.not_found":         {English: "customer not found", Indonesian: "pelanggan tidak ditemukan"},
+	"customer.id_not_found":      {English: "customer id %d not found", Indonesian: "pelanggan dengan id %d tidak ditemukan"},
+	"customer.has_transactions":  {English: "customer has transactions and cannot be deleted", Indonesian: "pelanggan sudah memiliki transaksi dan tidak dapat dihapus"},
+	"customer.phone_taken":       {English: "phone number is already registered to another customer", Indonesian: "nomor telepon sudah terdaftar untuk pelanggan lain"},
+	"customer.deleted":           {English: "Customer deleted successfully", Indonesian: "Pelanggan berhasil dihapus"},
+	"loyalty.points_balance":     {English: "not enough points: balance is %d", Indonesian: "poin tidak cukup: saldo %d"},
+	"loyalty.not_enough_points":  {English: "not enough points", Indonesian: "poin tidak cukup"},
+	"loyalty.redeem_exceeds_due": {English: "points redeemed exceed the amount due", Indonesian: "poin yang ditukar melebihi jumlah tagihan"},
+	"loyalty.duplicate_rule":     {English: "only one rule per category", Indonesian: "hanya boleh satu aturan per kategori"},
+	"loyalty.duplicate_tier":     {English: "tier names must be unique", Indonesian: "nama tier harus unik"},
+	"credit.not_allowed":         {English: "customer is not allowed to buy on credit", Indonesian: "pelanggan tidak boleh berbelanja secara kredit"},
+	"credit.limit_exceeded":      {English: "credit limit exceeded: %d of %d available", Indonesian: "batas kredit terlampaui: tersedia %d dari %d"},
+	"credit.repayment_exceeds":   {English: "repayment exceeds the outstanding balance of %d", Indonesian: "pembayaran melebihi sisa tagihan sebesar %d"},
+
+	// vouchers
+	"voucher.invalid_id":              {English: "Invalid voucher ID", Indonesian: "ID voucher tidak valid"},
+	"voucher.not_found":               {English: "voucher not found", Indonesian: "voucher tidak ditemukan"},
+	"voucher.code_taken":              {English: "voucher code already exists", Indonesian: "kode voucher sudah ada"},
+	"voucher.redeemed":                {English: "voucher has been redeemed, deactivate it instead", Indonesian: "voucher sudah pernah dipakai, nonaktifkan saja"},
+	"voucher.invalid_type":            {English: "voucher type must be fixed or percent", Indonesian: "tipe voucher harus fixed atau percent"},
+	"voucher.percent_over_100":        {English: "percent value cannot exceed 100", Indonesian: "nilai persen tidak boleh melebihi 100"},
+	"voucher.inactive":                {English: "voucher is not active", Indonesian: "voucher tidak aktif"},
+	"voucher.not_started":             {English: "voucher is not valid yet", Indonesian: "voucher belum berlaku"},
+	"voucher.expired":                 {English: "voucher has expired", Indonesian: "voucher sudah kedaluwarsa"},
+	"voucher.used_up":                 {English: "voucher has been fully redeemed", Indonesian: "kuota voucher sudah habis"},
+	"voucher.needs_customer":          {English: "voucher needs a customer", Indonesian: "voucher membutuhkan pelanggan"},
+	"voucher.customer_limit":          {English: "customer has used this voucher the maximum number of times", Indonesian: "pelanggan sudah memakai voucher ini sebanyak batas maksimal"},
+	"voucher.not_applicable_products": {English: "voucher does not apply to these products", Indonesian: "voucher tidak berlaku untuk produk ini"},
+	"voucher.min_spend":               {English: "voucher needs a minimum spend of %d", Indonesian: "voucher membutuhkan belanja minimal %d"},
+	"voucher.deleted":                 {English: "Voucher deleted successfully", Indonesian: "Voucher berhasil dihapus"},
+
+	// shifts and employees
+	"shift.invalid_id":          {English: "Invalid shift ID", Indonesian: "ID shift tidak valid"},
+	"shift.not_found":           {English: "shift not found", Indonesian: "shift tidak ditemukan"},
+	"shift.none_open":           {English: "no shift is open", Indonesian: "tidak ada shift yang sedang dibuka"},
+	"shift.already_open":        {English: "a shift is already open", Indonesian: "sudah ada shift yang dibuka"},
+	"shift.closed":              {English: "shift is closed, use its Z-report", Indonesian: "shift sudah ditutup, gunakan Z-report-nya"},
+	"shift.already_closed":      {English: "shift is already closed", Indonesian: "shift sudah ditutup"},
+	"zreport.invalid_number":    {English: "Invalid Z-report number", Indonesian: "Nomor Z-report tidak valid"},
+	"zreport.not_found":         {English: "z-report not found", Indonesian: "z-report tidak ditemukan"},
+	"employee.invalid_id":       {English: "Invalid employee ID", Indonesian: "ID karyawan tidak valid"},
+	"employee.not_found":        {English: "employee not found", Indonesian: "karyawan tidak ditemukan"},
+	"employee.not_linked":       {English: "your account is not linked to an employee", Indonesian: "akun Anda tidak terhubung ke karyawan"},
+	"employee.user_linked":      {English: "user is already linked to another employee", Indonesian: "user sudah terhubung ke karyawan lain"},
+	"employee.has_time_records": {English: "employee has time records and cannot be deleted, deactivate them instead", Indonesian: "karyawan sudah memiliki catatan waktu dan tidak dapat dihapus, nonaktifkan saja"},
+	"employee.inactive":         {English: "employee is inactive", Indonesian: "karyawan tidak aktif"},
+	"employee.clocked_in":       {English: "employee is already clocked in", Indonesian: "karyawan sudah clock-in"},
+	"employee.not_clocked_in":   {English: "employee is not clocked in", Indonesian: "karyawan belum clock-in"},
+	"employee.clock_out_first":  {English: "clock the employee out before deactivating them", Indonesian: "clock-out karyawan terlebih dahulu sebelum menonaktifkannya"},
+	"employee.deleted":          {English: "Employee deleted successfully", Indonesian: "Karyawan berhasil dihapus"},
+
+	// reports
+	"report.too_many_buckets": {English: "a time series by %s has at most %d buckets, shorten the date range", Indonesian: "deret waktu per %s maksimal %d periode, perpendek rentang tanggal"},
+	"report.abc_thresholds":   {English: "thresholds must satisfy 0 < a <= b <= 100", Indonesian: "ambang batas harus memenuhi 0 < a <= b <= 100"},
+	"forecast.history_short":  {English: "history must be at least holdout plus 7 days", Indonesian: "history minimal sebesar holdout ditambah 7 hari"},
+	"rollup.rebuilt":          {English: "Rollups rebuilt successfully", Indonesian: "Rollup berhasil dibangun ulang"},
+}
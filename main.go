@@ -4,13 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
+	"task-crud-kategori/auth"
+	"task-crud-kategori/clock"
 	"task-crud-kategori/database"
 	"task-crud-kategori/handlers"
+	"task-crud-kategori/i18n"
+	"task-crud-kategori/middleware"
 	"task-crud-kategori/repositories"
 	"task-crud-kategori/services"
+	"time"
+
+	_ "time/tzdata" // embed the timezone database, the deploy image may not ship one
 
 	"github.com/spf13/viper"
 )
@@ -19,6 +27,33 @@ import (
 type Config struct {
 	Port   string `mapstructure:"APP_PORT"`
 	DBConn string `mapstructure:"DB_CONN"`
+	// Timezone is the IANA name of the store timezone, e.g. Asia/Jakarta
+	Timezone string `mapstructure:"STORE_TIMEZONE"`
+	// CutoffHour is the local hour a business day starts (0 = midnight)
+	CutoffHour int `mapstructure:"BUSINESS_DAY_CUTOFF_HOUR"`
+	// JWTSecret signs access tokens. Left empty a random secret is used
+	// and every restart signs everyone out.
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+	// AccessTokenMinutes is how long an access token is valid
+	AccessTokenMinutes int `mapstructure:"ACCESS_TOKEN_TTL_MINUTES"`
+	// RefreshTokenHours is how long a session lasts without a refresh
+	RefreshTokenHours int `mapstructure:"REFRESH_TOKEN_TTL_HOURS"`
+	// AdminUsername and AdminPassword create the first user when the
+	// database has none
+	AdminUsername string `mapstructure:"ADMIN_USERNAME"`
+	AdminPassword string `mapstructure:"ADMIN_PASSWORD"`
+	// CORSAllowedOrigins is a comma separated list of browser origins
+	// allowed to call the API, or "*". Empty turns CORS off.
+	CORSAllowedOrigins string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	// CORSAllowCredentials lets browsers send credentials cross-origin
+	CORSAllowCredentials bool `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	// CORSMaxAge is how many seconds browsers cache a preflight answer
+	CORSMaxAge int `mapstructure:"CORS_MAX_AGE"`
+	// LogFormat is "text" or "json"
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+	// DefaultLanguage is the language of API messages, "id" or "en", for
+	// requests whose Accept-Language names neither
+	DefaultLanguage string `mapstructure:"DEFAULT_LANGUAGE"`
 }
 
 // main is the entry point of the application
@@ -32,10 +67,41 @@ func main() {
 		viper.SetConfigFile(".env")
 		_ = viper.ReadInConfig()
 	}
+	viper.SetDefault("ACCESS_TOKEN_TTL_MINUTES", 15)
+	viper.SetDefault("REFRESH_TOKEN_TTL_HOURS", 7*24)
+	viper.SetDefault("ADMIN_USERNAME", "admin")
+	viper.SetDefault("CORS_MAX_AGE", 600)
+	viper.SetDefault("LOG_FORMAT", "text")
+	viper.SetDefault("DEFAULT_LANGUAGE", "id")
 	// Map configuration to struct
 	config := Config{
-		Port:   viper.GetString("APP_PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:               viper.GetString("APP_PORT"),
+		DBConn:             viper.GetString("DB_CONN"),
+		Timezone:           viper.GetString("STORE_TIMEZONE"),
+		CutoffHour:         viper.GetInt("BUSINESS_DAY_CUTOFF_HOUR"),
+		JWTSecret:          viper.GetString("JWT_SECRET"),
+		AccessTokenMinutes: viper.GetInt("ACCESS_TOKEN_TTL_MINUTES"),
+		RefreshTokenHours:  viper.GetInt("REFRESH_TOKEN_TTL_HOURS"),
+		AdminUsername:      viper.GetString("ADMIN_USERNAME"),
+		AdminPassword:      viper.GetString("ADMIN_PASSWORD"),
+
+		CORSAllowedOrigins:   viper.GetString("CORS_ALLOWED_ORIGINS"),
+		CORSAllowCredentials: viper.GetBool("CORS_ALLOW_CREDENTIALS"),
+		CORSMaxAge:           viper.GetInt("CORS_MAX_AGE"),
+		LogFormat:            viper.GetString("LOG_FORMAT"),
+		DefaultLanguage:      viper.GetString("DEFAULT_LANGUAGE"),
+	}
+	// Setup logging, the log package writes through the same logger
+	logger := newLogger(config.LogFormat)
+	slog.SetDefault(logger)
+	// Setup business calendar
+	calendar, err := clock.NewCalendar(config.Timezone, config.CutoffHour)
+	if err != nil {
+		log.Fatal("Invalid store timezone:", err)
+	}
+	defaultLanguage, ok := i18n.Parse(config.DefaultLanguage)
+	if !ok {
+		log.Fatalf("Invalid DEFAULT_LANGUAGE %q, expected id or en", config.DefaultLanguage)
 	}
 	// Setup database
 	db, err := database.InitDB(config.DBConn)
@@ -49,41 +115,178 @@ func main() {
 	}
 	defer db.Close()
 
+	rollupRepo := repositories.NewRollupRepository(db, calendar)
+	rollupService := services.NewRollupService(rollupRepo)
+
+	// Maintenance commands: `app rollup rebuild` / `app rollup check`
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:], rollupService); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Reports fall back to raw transactions until the rollups are current
+	if err := rollupService.EnsureCurrent(); err != nil {
+		log.Println("Failed to rebuild sales rollups:", err)
+	}
+
 	// Setup repositories, services, and handlers
+	userRepo := repositories.NewUserRepository(db, calendar)
+	userService := services.NewUserService(userRepo)
+	userHandler := handlers.NewUserHandler(userService)
+	authService := services.NewAuthService(
+		userRepo,
+		auth.NewSigner(jwtSecret(config.JWTSecret)),
+		time.Duration(config.AccessTokenMinutes)*time.Minute,
+		time.Duration(config.RefreshTokenHours)*time.Hour,
+	)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db, calendar)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	authHandler := handlers.NewAuthHandler(authService, apiKeyService)
+
+	// A fresh install needs one user to sign in with
+	created, err := authService.Bootstrap(config.AdminUsername, config.AdminPassword)
+	if err != nil {
+		log.Fatal("Failed to create the first user:", err)
+	}
+	if created {
+		log.Println("Created user", config.AdminUsername)
+	} else if count, err := userRepo.Count(); err == nil && count == 0 {
+		log.Println("No users yet: set ADMIN_PASSWORD to create the first one")
+	}
+
+	auditRepo := repositories.NewAuditRepository(db, calendar)
+	auditService := services.NewAuditService(auditRepo, calendar)
+	auditHandler := handlers.NewAuditHandler(auditService)
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
-	productHandler := handlers.NewProductHandler(productService)
+	movementRepo := repositories.NewStockMovementRepository(db, calendar)
+	productService := services.NewProductService(productRepo, movementRepo, auditService)
 	categoryRepo := repositories.NewCategoryRepository(db)
-	categoryService := services.NewCategoryService(categoryRepo)
+	categoryService := services.NewCategoryService(categoryRepo, auditService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(db, transactionRepo)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	reportRepo := repositories.NewReportRepository(db)
-	reportService := services.NewReportService(reportRepo)
+	transactionRepo := repositories.NewTransactionRepository(db, calendar)
+	transactionService := services.NewTransactionService(transactionRepo, auditService, calendar)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, authService)
+	reportRepo := repositories.NewReportRepository(db, calendar)
+	reportService := services.NewReportService(reportRepo, calendar)
 	reportHandler := handlers.NewReportHandler(reportService)
+	productHandler := handlers.NewProductHandler(productService, reportService)
+	forecastService := services.NewForecastService(reportRepo, calendar)
+	forecastHandler := handlers.NewForecastHandler(forecastService)
+	shiftRepo := repositories.NewShiftRepository(db, calendar)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	rollupHandler := handlers.NewRollupHandler(rollupService)
+	customerRepo := repositories.NewCustomerRepository(db, calendar)
+	customerService := services.NewCustomerService(customerRepo, transactionRepo, calendar)
+	loyaltyRepo := repositories.NewLoyaltyRepository(db, calendar)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+	creditRepo := repositories.NewCreditRepository(db, calendar)
+	creditService := services.NewCreditService(creditRepo, calendar)
+	customerHandler := handlers.NewCustomerHandler(customerService, loyaltyService, creditService, authService)
+	voucherRepo := repositories.NewVoucherRepository(db, calendar)
+	voucherService := services.NewVoucherService(voucherRepo)
+	voucherHandler := handlers.NewVoucherHandler(voucherService)
+	employeeRepo := repositories.NewEmployeeRepository(db, calendar)
+	employeeService := services.NewEmployeeService(employeeRepo, calendar)
+	employeeHandler := handlers.NewEmployeeHandler(employeeService)
+	cartRepo := repositories.NewCartRepository(db, calendar)
+	cartService := services.NewCartService(cartRepo, auditService)
+	cartHandler := handlers.NewCartHandler(cartService, authService)
+	// Setup routes, see routes.go
+	api := &apiHandlers{
+		auth:         authHandler,
+		users:        userHandler,
+		apiKeys:      apiKeyHandler,
+		products:     productHandler,
+		categories:   categoryHandler,
+		transactions: transactionHandler,
+		carts:        cartHandler,
+		reports:      reportHandler,
+		rollups:      rollupHandler,
+		forecast:     forecastHandler,
+		customers:    customerHandler,
+		loyalty:      loyaltyHandler,
+		vouchers:     voucherHandler,
+		shifts:       shiftHandler,
+		employees:    employeeHandler,
+		audit:        auditHandler,
+	}
+	router := newRouter(authHandler, api.routes())
 
-	// Setup routes
-	http.HandleFunc("/api/produk", productHandler.HandleProducts)
-	http.HandleFunc("/api/produk/", productHandler.HandleProductByID)
-	http.HandleFunc("/api/categories", categoryHandler.HandleCategories)
-	http.HandleFunc("/api/categories/", categoryHandler.HandleCategoryByID)
-	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout)
-	http.HandleFunc("/api/report", reportHandler.GetSummary)
-	http.HandleFunc("/api/report/hari-ini", reportHandler.GetSummary)
-
-	// localhost:8080/health
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":  "OK",
-			"message": "API Running",
-		})
-	})
 	fmt.Println("Server running di localhost:" + config.Port)
 
-	err = http.ListenAndServe(":"+config.Port, nil)
+	// Every request gets an id, a language, an access log line and panic
+	// recovery; CORS answers preflights before authentication, and
+	// everything under /api/ needs a signed in user or an API key
+	server := middleware.Chain(router,
+		middleware.RequestID,
+		middleware.Language(defaultLanguage),
+		middleware.AccessLog(logger),
+		middleware.Recover(logger),
+		middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   strings.Split(config.CORSAllowedOrigins, ","),
+			AllowCredentials: config.CORSAllowCredentials,
+			MaxAge:           config.CORSMaxAge,
+		}),
+		authHandler.RequireAuth,
+	)
+	err = http.ListenAndServe(":"+config.Port, server)
 	if err != nil {
 		fmt.Println("gagal running server")
 	}
 }
+
+// newLogger returns the application logger writing text or JSON lines
+// to stdout
+func newLogger(format string) *slog.Logger {
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, nil))
+}
+
+// jwtSecret returns the configured token secret, or a random one
+func jwtSecret(configured string) []byte {
+	if configured != "" {
+		return []byte(configured)
+	}
+	secret, err := auth.RandomToken(32)
+	if err != nil {
+		log.Fatal("Failed to generate a token secret:", err)
+	}
+	log.Println("JWT_SECRET is not set: using a random secret, sessions end when the server restarts")
+	return []byte(secret)
+}
+
+// runCommand runs a maintenance command instead of the HTTP server
+func runCommand(args []string, rollupService *services.RollupService) error {
+	switch strings.Join(args, " ") {
+	case "rollup rebuild":
+		days, err := rollupService.Rebuild()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rollups rebuilt for %d business days\n", days)
+		return nil
+	case "rollup check":
+		result, err := rollupService.Check()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		if !result.Consistent {
+			os.Exit(1)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, expected \"rollup rebuild\" or \"rollup check\"", strings.Join(args, " "))
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig says which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as https://kasir.example.com,
+	// or "*" for any origin. Empty turns CORS off.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and auth headers. It is
+	// ignored for "*", which browsers refuse to combine with credentials.
+	AllowCredentials bool
+	// MaxAge is how many seconds a browser may cache a preflight answer
+	MaxAge int
+}
+
+// corsMethods and corsHeaders are what the API takes from a browser
+const (
+	corsMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsHeaders = "Authorization, Content-Type, X-API-Key, X-Request-ID, X-Supervisor, X-Supervisor-Pin"
+)
+
+// CORS answers preflight requests and adds the CORS headers for allowed
+// origins. It has to run before authentication, browsers send preflights
+// without credentials.
+func CORS(config CORSConfig) Middleware {
+	allowAll := false
+	allowed := map[string]bool{}
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			allowAll = true
+		} else if origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !allowAll && len(allowed) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if !allowAll && !allowed[origin] {
+				// no CORS headers, the browser blocks the response
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAll {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if config.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", corsMethods)
+				h.Set("Access-Control-Allow-Headers", corsHeaders)
+				if config.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Expose-Headers", RequestIDHeader)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
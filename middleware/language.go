@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"task-crud-kategori/i18n"
+)
+
+// Language negotiates the language of a request from its Accept-Language
+// header, fallback when it names none the API speaks. The language goes in
+// the request context and the Content-Language header of the response,
+// which is what error responses are written in.
+func Language(fallback i18n.Lang) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lang := i18n.Negotiate(r.Header.Get("Accept-Language"), fallback)
+			w.Header().Set("Content-Language", string(lang))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+		})
+	}
+}
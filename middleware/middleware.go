@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"task-crud-kategori/auth"
+)
+
+// Middleware wraps a handler with behaviour of its own
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h in middlewares, the first one outermost, so
+// Chain(h, a, b) serves a request through a, then b, then h
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// RequestIDHeader carries the request id both ways
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest id taken from a client, longer ones
+// are replaced rather than written to the logs
+const maxRequestIDLength = 64
+
+// RequestID gives every request an id: the one the client sent in the
+// X-Request-ID header, so a request can be followed across services, or
+// a new one. The id is echoed in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id, _ = auth.RandomToken(8)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// GetRequestID returns the id RequestID gave a request
+func GetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
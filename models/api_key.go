@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// APIKey lets a script or device call the API without signing in. It can
+// do what its Scopes, a list of permissions, allow. Key is the secret
+// itself and is only returned when the key is created or rotated.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key grants permission
+func (k *APIKey) HasScope(permission string) bool {
+	for _, s := range k.Scopes {
+		if s == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest creates an API key. Without ExpiresAt it never expires.
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Audit actor types
+const (
+	ActorUser   = "user"
+	ActorAPIKey = "api_key"
+	ActorSystem = "system"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditRefund = "refund"
+)
+
+// Audited entity types
+const (
+	EntityProduct     = "product"
+	EntityCategory    = "category"
+	EntityTransaction = "transaction"
+)
+
+// Actor is who made a change: a signed in user or an API key, plus the
+// id of the request it came in on. The zero value is the system.
+type Actor struct {
+	UserID    *int
+	APIKeyID  *int
+	Name      string
+	RequestID string
+}
+
+// Type returns the audit actor type and id of a
+func (a Actor) Type() (string, *int) {
+	switch {
+	case a.UserID != nil:
+		return ActorUser, a.UserID
+	case a.APIKeyID != nil:
+		return ActorAPIKey, a.APIKeyID
+	default:
+		return ActorSystem, nil
+	}
+}
+
+// FieldChange is the value of one field before and after a change; nil
+// on a create or delete side
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry is one data-changing call
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	ActorType  string                 `json:"actor_type"`
+	ActorID    *int                   `json:"actor_id"`
+	ActorName  string                 `json:"actor_name"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int                    `json:"entity_id"`
+	Changes    map[string]FieldChange `json:"changes"`
+	RequestID  string                 `json:"request_id"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditFilter narrows the audit log; zero fields do not filter.
+// StartDate and EndDate are business dates, both inclusive.
+type AuditFilter struct {
+	EntityType string
+	EntityID   int
+	ActorType  string
+	ActorID    int
+	Action     string
+	RequestID  string
+	StartDate  time.Time
+	EndDate    time.Time
+	Limit      int
+}
@@ -0,0 +1,44 @@
+package models
+
+// ProductPair is how strongly two products are bought together
+type ProductPair struct {
+	ProductAID     int     `json:"product_a_id"`
+	ProductAName   string  `json:"product_a_name"`
+	ProductBID     int     `json:"product_b_id"`
+	ProductBName   string  `json:"product_b_name"`
+	Transactions   int     `json:"transactions"`
+	Support        float64 `json:"support"`
+	ConfidenceAToB float64 `json:"confidence_a_to_b"`
+	ConfidenceBToA float64 `json:"confidence_b_to_a"`
+	Lift           float64 `json:"lift"`
+}
+
+// BasketAnalysis is the response of GET /api/report/basket
+type BasketAnalysis struct {
+	StartDate    string        `json:"start_date"`
+	EndDate      string        `json:"end_date"`
+	Transactions int           `json:"transactions"`
+	MinCount     int           `json:"min_count"`
+	Pairs        []ProductPair `json:"pairs"`
+}
+
+// Association is a product bought together with another one. Confidence
+// is the share of baskets with the other product that also contain this one.
+type Association struct {
+	ProductID    int     `json:"product_id"`
+	Name         string  `json:"name"`
+	Transactions int     `json:"transactions"`
+	Support      float64 `json:"support"`
+	Confidence   float64 `json:"confidence"`
+	Lift         float64 `json:"lift"`
+}
+
+// BoughtTogether is the response of GET /api/produk/{id}/bought-together
+type BoughtTogether struct {
+	ProductID    int           `json:"product_id"`
+	Name         string        `json:"name"`
+	StartDate    string        `json:"start_date"`
+	EndDate      string        `json:"end_date"`
+	Transactions int           `json:"transactions"`
+	Associations []Association `json:"associations"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Cart statuses
+const (
+	CartOpen      = "open"
+	CartConverted = "converted"
+	CartCancelled = "cancelled"
+	CartExpired   = "expired"
+)
+
+// Cart is a parked basket a cashier can come back to. With Reserve set
+// its lines hold stock back from other sales until the cart is checked
+// out, cancelled or expires. Every change pushes ExpiresAt TTLMinutes out.
+type Cart struct {
+	ID            int        `json:"id"`
+	Label         string     `json:"label"`
+	CustomerID    *int       `json:"customer_id"`
+	Reserve       bool       `json:"reserve"`
+	Status        string     `json:"status"`
+	TTLMinutes    int        `json:"ttl_minutes"`
+	TransactionID *int       `json:"transaction_id"`
+	Total         int        `json:"total"`
+	Items         []CartItem `json:"items"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CartItem is one line of a cart, priced at the current product price
+type CartItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Price       int    `json:"price"`
+	Quantity    int    `json:"quantity"`
+	Subtotal    int    `json:"subtotal"`
+}
+
+// CartRequest creates a cart
+type CartRequest struct {
+	Label      string         `json:"label"`
+	CustomerID *int           `json:"customer_id"`
+	Reserve    bool           `json:"reserve"`
+	TTLMinutes int            `json:"ttl_minutes"`
+	Items      []CheckoutItem `json:"items"`
+}
+
+// CartCheckoutRequest turns a cart into a sale. The items and customer
+// come from the cart; CustomerID overrides the cart's customer when set.
+type CartCheckoutRequest struct {
+	PaymentMethod string `json:"payment_method"`
+	Discount      int    `json:"discount"`
+	CustomerID    *int   `json:"customer_id,omitempty"`
+	RedeemPoints  int    `json:"redeem_points"`
+	VoucherCode   string `json:"voucher_code,omitempty"`
+	// UserID is the signed in cashier and ApprovedBy the supervisor who
+	// approved a discount, set by the handler
+	UserID     *int `json:"-"`
+	ApprovedBy *int `json:"-"`
+}
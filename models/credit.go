@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Repayment is money a customer paid towards their kasbon
+type Repayment struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	Amount        int       `json:"amount"`
+	PaymentMethod string    `json:"payment_method"`
+	ShiftID       *int      `json:"shift_id"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type RepaymentRequest struct {
+	Amount        int    `json:"amount"`
+	PaymentMethod string `json:"payment_method"`
+	Note          string `json:"note"`
+}
+
+// CreditInvoice is a credit sale that is not fully repaid. Repayments
+// settle the oldest sales first.
+type CreditInvoice struct {
+	TransactionID int    `json:"transaction_id"`
+	BusinessDate  string `json:"business_date"`
+	Amount        int    `json:"amount"`
+	Paid          int    `json:"paid"`
+	Outstanding   int    `json:"outstanding"`
+	AgeDays       int    `json:"age_days"`
+}
+
+// CreditAccount is a customer's kasbon. Money the store owes the customer,
+// e.g. after refunding a repaid credit sale, is store credit; it does not
+// raise the available credit.
+type CreditAccount struct {
+	CustomerID   int             `json:"customer_id"`
+	Name         string          `json:"name"`
+	CreditLimit  int             `json:"credit_limit"`
+	Balance      int             `json:"balance"`
+	StoreCredit  int             `json:"store_credit"`
+	Available    int             `json:"available"`
+	OpenInvoices []CreditInvoice `json:"open_invoices"`
+	Repayments   []Repayment     `json:"repayments"`
+}
+
+// AgingBuckets split an outstanding amount by the age of the sales
+type AgingBuckets struct {
+	Days0To30  int `json:"days_0_30"`
+	Days31To60 int `json:"days_31_60"`
+	Over60     int `json:"days_over_60"`
+	Total      int `json:"total"`
+}
+
+// CustomerAging is the outstanding kasbon of one customer
+type CustomerAging struct {
+	CustomerID  int    `json:"customer_id"`
+	Name        string `json:"name"`
+	CreditLimit int    `json:"credit_limit"`
+	AgingBuckets
+	OldestDays int `json:"oldest_days"`
+}
+
+// AgingReport is the response of GET /api/report/receivables-aging
+type AgingReport struct {
+	AsOf      string          `json:"as_of"`
+	Totals    AgingBuckets    `json:"totals"`
+	Customers []CustomerAging `json:"customers"`
+}
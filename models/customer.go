@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Customer is a known buyer that transactions can be attached to
+type Customer struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Phone       string    `json:"phone"`
+	Email       string    `json:"email"`
+	Notes       string    `json:"notes"`
+	CreditLimit int       `json:"credit_limit"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CustomerValue is what a customer has spent over their whole history.
+// LifetimeValue is the gross spend minus refunds.
+type CustomerValue struct {
+	CustomerID            int        `json:"customer_id"`
+	Name                  string     `json:"name"`
+	Transactions          int        `json:"transactions"`
+	RefundedTransactions  int        `json:"refunded_transactions"`
+	ItemsBought           int        `json:"items_bought"`
+	GrossSpend            int        `json:"gross_spend"`
+	Refunds               int        `json:"refunds"`
+	LifetimeValue         int        `json:"lifetime_value"`
+	AverageBasket         float64    `json:"average_basket"`
+	FirstPurchaseAt       *time.Time `json:"first_purchase_at"`
+	LastPurchaseAt        *time.Time `json:"last_purchase_at"`
+	DaysSinceLastPurchase *int       `json:"days_since_last_purchase"`
+}
+
+// CustomerHistory is the response of GET /api/customers/{id}/transactions
+type CustomerHistory struct {
+	Customer     Customer      `json:"customer"`
+	Transactions []Transaction `json:"transactions"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Employee is a staff profile. UserID links it to the account the
+// employee signs in with; staff who never ring up sales need none.
+type Employee struct {
+	ID       int    `json:"id"`
+	UserID   *int   `json:"user_id"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Position string `json:"position"`
+	// HiredOn is a date, YYYY-MM-DD
+	HiredOn   string     `json:"hired_on"`
+	Active    bool       `json:"active"`
+	ClockedIn *TimeEntry `json:"clocked_in"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// EmployeeRequest creates or changes an employee. On update, empty fields
+// keep their value; UnlinkUser removes the link to a user account.
+type EmployeeRequest struct {
+	UserID     *int   `json:"user_id,omitempty"`
+	UnlinkUser bool   `json:"unlink_user,omitempty"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Position   string `json:"position"`
+	HiredOn    string `json:"hired_on"`
+	Active     *bool  `json:"active,omitempty"`
+}
+
+// TimeEntry is one stretch of work, from clock-in to clock-out. Minutes
+// runs up to now while the employee is still clocked in.
+type TimeEntry struct {
+	ID         int        `json:"id"`
+	EmployeeID int        `json:"employee_id"`
+	ClockIn    time.Time  `json:"clock_in"`
+	ClockOut   *time.Time `json:"clock_out"`
+	Minutes    int        `json:"minutes"`
+	Note       string     `json:"note"`
+}
+
+// ClockRequest is the optional body of a clock-in or clock-out
+type ClockRequest struct {
+	Note string `json:"note"`
+}
+
+// Timesheet is the time an employee worked over some business days
+type Timesheet struct {
+	EmployeeID   int         `json:"employee_id"`
+	EmployeeName string      `json:"employee_name"`
+	StartDate    string      `json:"start_date"`
+	EndDate      string      `json:"end_date"`
+	TotalMinutes int         `json:"total_minutes"`
+	Entries      []TimeEntry `json:"entries"`
+}
+
+// CashierShiftSales is what one cashier rang up in one register shift.
+// Voids are the refunds the cashier processed in that shift.
+type CashierShiftSales struct {
+	ShiftID       *int       `json:"shift_id"`
+	ShiftOpenedAt *time.Time `json:"shift_opened_at"`
+	UserID        *int       `json:"user_id"`
+	EmployeeID    *int       `json:"employee_id"`
+	Cashier       string     `json:"cashier"`
+	Transactions  int        `json:"transactions"`
+	Sales         int        `json:"sales"`
+	Voids         int        `json:"voids"`
+	VoidAmount    int        `json:"void_amount"`
+	NetSales      int        `json:"net_sales"`
+	AverageBasket float64    `json:"average_basket"`
+}
+
+// CashierReport lists the sales of every cashier per shift in a period;
+// Cashiers sums them per cashier over all shifts
+type CashierReport struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	Shifts    []CashierShiftSales `json:"shifts"`
+	Cashiers  []CashierShiftSales `json:"cashiers"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// DemandHistory is a product with its units sold per business day
+type DemandHistory struct {
+	ProductID int
+	Name      string
+	Stock     int
+	CreatedAt *time.Time
+	Daily     map[string]int
+}
+
+// ForecastPoint is the projected demand of one business day
+type ForecastPoint struct {
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
+}
+
+// ProductForecast is the demand projection of a product. DaysOfStock and
+// StockoutDate are empty when no demand is expected.
+type ProductForecast struct {
+	ProductID     int             `json:"product_id"`
+	Name          string          `json:"name"`
+	Stock         int             `json:"stock"`
+	HistoryDays   int             `json:"history_days"`
+	AverageDaily  float64         `json:"average_daily"`
+	ForecastTotal float64         `json:"forecast_total"`
+	DaysOfStock   *float64        `json:"days_of_stock"`
+	StockoutDate  *string         `json:"stockout_date"`
+	Forecast      []ForecastPoint `json:"forecast"`
+}
+
+// ForecastReport is the response of GET /api/forecast
+type ForecastReport struct {
+	Method       string            `json:"method"`
+	HistoryStart string            `json:"history_start"`
+	HistoryEnd   string            `json:"history_end"`
+	Days         int               `json:"days"`
+	Products     []ProductForecast `json:"products"`
+}
+
+// BacktestResult is the accuracy of one forecast method
+type BacktestResult struct {
+	Method   string  `json:"method"`
+	Products int     `json:"products"`
+	Points   int     `json:"points"`
+	MAE      float64 `json:"mae"`
+	RMSE     float64 `json:"rmse"`
+	WAPE     float64 `json:"wape"`
+	Bias     float64 `json:"bias"`
+}
+
+// BacktestReport is the response of GET /api/forecast/backtest
+type BacktestReport struct {
+	HistoryStart string           `json:"history_start"`
+	HistoryEnd   string           `json:"history_end"`
+	Holdout      int              `json:"holdout"`
+	Best         string           `json:"best"`
+	Results      []BacktestResult `json:"results"`
+}
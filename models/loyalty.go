@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Loyalty ledger entry types
+const (
+	PointsEarn          = "earn"
+	PointsRedeem        = "redeem"
+	PointsExpire        = "expire"
+	PointsReverseEarn   = "reverse_earn"
+	PointsReverseRedeem = "reverse_redeem"
+)
+
+// LoyaltyRule is how many Rupiah paid earn one point in a category.
+// Category 0 applies to every category without its own rule; 0 Rupiah per
+// point means the category earns nothing.
+type LoyaltyRule struct {
+	CategoryID     int `json:"category_id"`
+	RupiahPerPoint int `json:"rupiah_per_point"`
+}
+
+// LoyaltyTier is reached with MinPoints earned over the membership and
+// gives a discount on every purchase
+type LoyaltyTier struct {
+	Name            string  `json:"name"`
+	MinPoints       int     `json:"min_points"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// LoyaltyProgram is the whole configuration of the loyalty program.
+// PointValue is what one point is worth in Rupiah when redeemed.
+type LoyaltyProgram struct {
+	PointValue int           `json:"point_value"`
+	ExpiryDays int           `json:"expiry_days"`
+	Rules      []LoyaltyRule `json:"rules"`
+	Tiers      []LoyaltyTier `json:"tiers"`
+}
+
+// LoyaltyEntry is one movement on a customer's points ledger. Remaining
+// and ExpiresAt are only set on earned points.
+type LoyaltyEntry struct {
+	ID            int        `json:"id"`
+	CustomerID    int        `json:"customer_id"`
+	Type          string     `json:"type"`
+	Points        int        `json:"points"`
+	Remaining     *int       `json:"remaining,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	TransactionID *int       `json:"transaction_id"`
+	Reference     string     `json:"reference"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ExpiringPoints are points that expire together
+type ExpiringPoints struct {
+	Points    int       `json:"points"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LoyaltyAccount is a customer's points balance and tier
+type LoyaltyAccount struct {
+	CustomerID          int              `json:"customer_id"`
+	Balance             int              `json:"balance"`
+	BalanceValue        int              `json:"balance_value"`
+	LifetimePoints      int              `json:"lifetime_points"`
+	Tier                string           `json:"tier"`
+	TierDiscountPercent float64          `json:"tier_discount_percent"`
+	NextTier            string           `json:"next_tier,omitempty"`
+	PointsToNextTier    *int             `json:"points_to_next_tier,omitempty"`
+	Expiring            []ExpiringPoints `json:"expiring"`
+}
+
+// LoyaltyCheckout is what the loyalty program did on a checkout
+type LoyaltyCheckout struct {
+	Tier                string  `json:"tier"`
+	TierDiscountPercent float64 `json:"tier_discount_percent"`
+	TierDiscount        int     `json:"tier_discount"`
+	PointsRedeemed      int     `json:"points_redeemed"`
+	RedeemValue         int     `json:"redeem_value"`
+	PointsEarned        int     `json:"points_earned"`
+	Balance             int     `json:"balance"`
+}
+
+// ExpireResult is the response of POST /api/loyalty/expire
+type ExpireResult struct {
+	Lots   int `json:"lots"`
+	Points int `json:"points"`
+}
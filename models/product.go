@@ -1,10 +1,37 @@
 package models
 
+import "time"
+
 type Product struct {
 	ID         int       `json:"id"`
 	Name       string    `json:"name"`
 	Price      int       `json:"price"`
+	Cost       int       `json:"cost"`
 	Stock      int       `json:"stock"`
 	CategoryID int       `json:"category_id"`
 	Category   *Category `json:"category,omitempty"`
 }
+
+// Stock movement types
+const (
+	MovementInitial    = "initial"
+	MovementAdjustment = "adjustment"
+	MovementSale       = "sale"
+	MovementRefund     = "refund"
+	MovementPrice      = "price_change"
+)
+
+// StockMovement is one change of a product's stock. Quantity is the signed
+// change; UnitCost and UnitPrice are the product's cost and price right
+// after the movement, nil for movements backfilled from old sales.
+type StockMovement struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	StockAfter *int      `json:"stock_after"`
+	UnitCost   *int      `json:"unit_cost"`
+	UnitPrice  *int      `json:"unit_price"`
+	Reference  string    `json:"reference"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type BestProduct struct {
 	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
@@ -9,4 +11,256 @@ type ReportSummary struct {
 	TotalRevenue   int         `json:"total_revenue"`
 	TotalTransaksi int         `json:"total_transaksi"`
 	ProdukTerlaris BestProduct `json:"produk_terlaris"`
+
+	// optional breakdowns, see ReportOptions
+	TopProducts    []ProductSales  `json:"top_products,omitempty"`
+	BottomProducts []ProductSales  `json:"bottom_products,omitempty"`
+	Categories     []CategorySales `json:"categories,omitempty"`
+}
+
+// ReportSummaryEnglish is a ReportSummary with English field names, for
+// clients that ask for them with field_names=en
+type ReportSummaryEnglish struct {
+	TotalRevenue      int                `json:"total_revenue"`
+	TotalTransactions int                `json:"total_transactions"`
+	BestProduct       BestProductEnglish `json:"best_product"`
+
+	TopProducts    []ProductSales  `json:"top_products,omitempty"`
+	BottomProducts []ProductSales  `json:"bottom_products,omitempty"`
+	Categories     []CategorySales `json:"categories,omitempty"`
+}
+
+type BestProductEnglish struct {
+	Name         string `json:"name"`
+	QuantitySold int    `json:"quantity_sold"`
+}
+
+// English returns the summary with English field names
+func (s ReportSummary) English() ReportSummaryEnglish {
+	return ReportSummaryEnglish{
+		TotalRevenue:      s.TotalRevenue,
+		TotalTransactions: s.TotalTransaksi,
+		BestProduct: BestProductEnglish{
+			Name:         s.ProdukTerlaris.Nama,
+			QuantitySold: s.ProdukTerlaris.QtyTerjual,
+		},
+		TopProducts:    s.TopProducts,
+		BottomProducts: s.BottomProducts,
+		Categories:     s.Categories,
+	}
+}
+
+// ReportOptions selects the optional breakdowns of a report summary
+type ReportOptions struct {
+	Top        int
+	Bottom     int
+	RankBy     string // "quantity" or "revenue"
+	ByCategory bool
+}
+
+// ProductSales is the quantity and revenue of one product in a period,
+// with its share of the period totals in percent
+type ProductSales struct {
+	ProductID     int     `json:"product_id"`
+	Name          string  `json:"name"`
+	CategoryID    int     `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	Quantity      int     `json:"quantity"`
+	Revenue       int     `json:"revenue"`
+	QuantityShare float64 `json:"quantity_share"`
+	RevenueShare  float64 `json:"revenue_share"`
+}
+
+// CategorySales is the quantity and revenue of one category in a period
+type CategorySales struct {
+	CategoryID    int     `json:"category_id"`
+	Name          string  `json:"name"`
+	Products      int     `json:"products"`
+	Quantity      int     `json:"quantity"`
+	Revenue       int     `json:"revenue"`
+	QuantityShare float64 `json:"quantity_share"`
+	RevenueShare  float64 `json:"revenue_share"`
+}
+
+// DailySales is the raw per-day aggregate used to build time-series reports.
+// Figures are net of the refunds paid out that day.
+type DailySales struct {
+	Date         string
+	Revenue      int
+	Transactions int
+	ItemsSold    int
+}
+
+// SalesBucket is one period of a time-series report
+type SalesBucket struct {
+	Period        string  `json:"period"`
+	StartDate     string  `json:"start_date"`
+	EndDate       string  `json:"end_date"`
+	Revenue       int     `json:"revenue"`
+	Transactions  int     `json:"transactions"`
+	ItemsSold     int     `json:"items_sold"`
+	AverageBasket float64 `json:"average_basket"`
+}
+
+// SalesGrowth holds the percentage change against the previous period.
+// A nil value means the previous period had nothing to compare against.
+type SalesGrowth struct {
+	Revenue       *float64 `json:"revenue_pct"`
+	Transactions  *float64 `json:"transactions_pct"`
+	ItemsSold     *float64 `json:"items_sold_pct"`
+	AverageBasket *float64 `json:"average_basket_pct"`
+}
+
+// SalesPeriod is a bucketed date range with its totals
+type SalesPeriod struct {
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Buckets   []SalesBucket `json:"buckets"`
+	Totals    SalesBucket   `json:"totals"`
+}
+
+// TimeSeriesReport is the response of GET /api/report/timeseries
+type TimeSeriesReport struct {
+	Interval string       `json:"interval"`
+	Current  SalesPeriod  `json:"current"`
+	Previous *SalesPeriod `json:"previous,omitempty"`
+	Growth   *SalesGrowth `json:"growth,omitempty"`
+}
+
+// HeatmapCell is the net sales of one weekday and hour-of-day in the store
+// timezone: refunds count against the hour they were paid out. Weekday
+// follows ISO 8601: 1 is Monday, 7 is Sunday.
+type HeatmapCell struct {
+	Weekday       int     `json:"weekday"`
+	DayName       string  `json:"day_name"`
+	Hour          int     `json:"hour"`
+	Revenue       int     `json:"revenue"`
+	Transactions  int     `json:"transactions"`
+	AverageBasket float64 `json:"average_basket"`
+}
+
+// HourTotal is the sales of one hour-of-day over all weekdays
+type HourTotal struct {
+	Hour          int     `json:"hour"`
+	Revenue       int     `json:"revenue"`
+	Transactions  int     `json:"transactions"`
+	AverageBasket float64 `json:"average_basket"`
+}
+
+// WeekdayTotal is the sales of one weekday over all hours
+type WeekdayTotal struct {
+	Weekday       int     `json:"weekday"`
+	DayName       string  `json:"day_name"`
+	Revenue       int     `json:"revenue"`
+	Transactions  int     `json:"transactions"`
+	AverageBasket float64 `json:"average_basket"`
+}
+
+// SalesHeatmap is the response of GET /api/report/heatmap
+type SalesHeatmap struct {
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+	Timezone  string         `json:"timezone"`
+	Cells     []HeatmapCell  `json:"cells"`
+	ByHour    []HourTotal    `json:"by_hour"`
+	ByWeekday []WeekdayTotal `json:"by_weekday"`
+	PeakHours []HeatmapCell  `json:"peak_hours"`
+}
+
+// AbcItem is a product with its ABC class. Products are ranked by the
+// chosen measure; class A covers the first A% of the cumulative total,
+// class B up to B%, class C the rest.
+type AbcItem struct {
+	ProductSales
+	Rank            int     `json:"rank"`
+	CumulativeShare float64 `json:"cumulative_share"`
+	Class           string  `json:"class"`
+}
+
+// AbcClassSummary is the size and weight of one ABC class
+type AbcClassSummary struct {
+	Class        string  `json:"class"`
+	Products     int     `json:"products"`
+	ProductShare float64 `json:"product_share"`
+	Quantity     int     `json:"quantity"`
+	Revenue      int     `json:"revenue"`
+	Share        float64 `json:"share"`
+}
+
+// AbcReport is the response of GET /api/report/abc
+type AbcReport struct {
+	StartDate  string            `json:"start_date"`
+	EndDate    string            `json:"end_date"`
+	By         string            `json:"by"`
+	ThresholdA float64           `json:"threshold_a"`
+	ThresholdB float64           `json:"threshold_b"`
+	Classes    []AbcClassSummary `json:"classes"`
+	Products   []AbcItem         `json:"products"`
+}
+
+// StockAgingItem is a product in stock with its recent sales
+type StockAgingItem struct {
+	ProductID         int        `json:"product_id"`
+	Name              string     `json:"name"`
+	CategoryID        int        `json:"category_id"`
+	CategoryName      string     `json:"category_name"`
+	Stock             int        `json:"stock"`
+	Price             int        `json:"price"`
+	Cost              int        `json:"cost"`
+	StockValue        int        `json:"stock_value"`
+	CostValue         int        `json:"cost_value"`
+	QuantitySold      int        `json:"quantity_sold"`
+	LastSoldAt        *time.Time `json:"last_sold_at"`
+	DaysSinceLastSale *int       `json:"days_since_last_sale"`
+}
+
+// DeadStockReport is the response of GET /api/report/dead-stock. Dead
+// products did not sell at all in the window, slow movers sold at most
+// SlowThreshold units. Stock value is at the selling price, cost value at
+// the cost price.
+type DeadStockReport struct {
+	Days           int              `json:"days"`
+	Since          string           `json:"since"`
+	SlowThreshold  int              `json:"slow_threshold"`
+	Dead           []StockAgingItem `json:"dead"`
+	DeadStockValue int              `json:"dead_stock_value"`
+	DeadCostValue  int              `json:"dead_cost_value"`
+	Slow           []StockAgingItem `json:"slow"`
+	SlowStockValue int              `json:"slow_stock_value"`
+	SlowCostValue  int              `json:"slow_cost_value"`
+}
+
+// ValuationItem is the reconstructed stock of one product at a point in time
+type ValuationItem struct {
+	ProductID    int    `json:"product_id"`
+	Name         string `json:"name"`
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Quantity     int    `json:"quantity"`
+	UnitCost     int    `json:"unit_cost"`
+	UnitPrice    int    `json:"unit_price"`
+	CostValue    int    `json:"cost_value"`
+	RetailValue  int    `json:"retail_value"`
+}
+
+// CategoryValuation sums the valuation of the products of one category
+type CategoryValuation struct {
+	CategoryID  int    `json:"category_id"`
+	Name        string `json:"name"`
+	Products    int    `json:"products"`
+	Quantity    int    `json:"quantity"`
+	CostValue   int    `json:"cost_value"`
+	RetailValue int    `json:"retail_value"`
+}
+
+// InventoryValuation is the response of GET /api/report/inventory-valuation.
+// Quantities are the current stock minus every movement after AsOfTime.
+type InventoryValuation struct {
+	AsOf             string              `json:"as_of"`
+	AsOfTime         time.Time           `json:"as_of_time"`
+	TotalQuantity    int                 `json:"total_quantity"`
+	TotalCostValue   int                 `json:"total_cost_value"`
+	TotalRetailValue int                 `json:"total_retail_value"`
+	Categories       []CategoryValuation `json:"categories"`
+	Products         []ValuationItem     `json:"products"`
 }
@@ -0,0 +1,110 @@
+package models
+
+import "sort"
+
+// Roles a user can have
+const (
+	RoleCashier    = "cashier"
+	RoleSupervisor = "supervisor"
+	RoleOwner      = "owner"
+)
+
+// Permissions checked on the API routes
+const (
+	PermProductRead       = "product:read"
+	PermProductWrite      = "product:write"
+	PermCategoryRead      = "category:read"
+	PermCategoryWrite     = "category:write"
+	PermTransactionCreate = "transaction:create"
+	PermTransactionRead   = "transaction:read"
+	PermTransactionRefund = "transaction:refund"
+	PermPriceOverride     = "price:override"
+	PermCustomerRead      = "customer:read"
+	PermCustomerWrite     = "customer:write"
+	PermCreditManage      = "credit:manage"
+	PermVoucherRead       = "voucher:read"
+	PermVoucherWrite      = "voucher:write"
+	PermShiftRead         = "shift:read"
+	PermShiftManage       = "shift:manage"
+	PermReportRead        = "report:read"
+	PermSettingsWrite     = "settings:write"
+	PermUserManage        = "user:manage"
+	PermAPIKeyManage      = "apikey:manage"
+	PermAuditRead         = "audit:read"
+	PermEmployeeRead      = "employee:read"
+	PermEmployeeWrite     = "employee:write"
+	PermTimeClock         = "timeclock:punch"
+)
+
+var cashierPermissions = []string{
+	PermProductRead, PermCategoryRead,
+	PermTransactionCreate, PermTransactionRead,
+	PermCustomerRead, PermCustomerWrite,
+	PermVoucherRead,
+	PermShiftRead, PermShiftManage,
+	PermTimeClock,
+}
+
+var supervisorPermissions = append([]string{
+	PermProductWrite, PermCategoryWrite,
+	PermTransactionRefund, PermPriceOverride,
+	PermVoucherWrite,
+	PermCreditManage,
+	PermReportRead,
+	PermEmployeeRead, PermEmployeeWrite,
+}, cashierPermissions...)
+
+var ownerPermissions = append([]string{
+	PermSettingsWrite, PermUserManage, PermAPIKeyManage, PermAuditRead,
+}, supervisorPermissions...)
+
+// RolePermissions lists what each role may do
+var RolePermissions = map[string][]string{
+	RoleCashier:    cashierPermissions,
+	RoleSupervisor: supervisorPermissions,
+	RoleOwner:      ownerPermissions,
+}
+
+// overridable permissions can be lent to a user for one request by a
+// supervisor entering their PIN
+var overridable = map[string]bool{
+	PermTransactionRefund: true,
+	PermPriceOverride:     true,
+}
+
+// IsValidScope reports whether permission can be given to an API key.
+// Keys cannot manage users or other keys.
+func IsValidScope(permission string) bool {
+	if permission == PermUserManage || permission == PermAPIKeyManage {
+		return false
+	}
+	return HasPermission(RoleOwner, permission)
+}
+
+// IsValidRole reports whether role is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverridable reports whether a supervisor PIN can grant permission
+func IsOverridable(permission string) bool {
+	return overridable[permission]
+}
+
+// PermissionsOf returns the permissions of role, sorted
+func PermissionsOf(role string) []string {
+	perms := append([]string{}, RolePermissions[role]...)
+	sort.Strings(perms)
+	return perms
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// DailyRollup is the pre-aggregated sales of one business day. Sales are
+// booked on the day of the sale, refunds on the day they are paid out.
+type DailyRollup struct {
+	Date         string `json:"business_date"`
+	Transactions int    `json:"transactions"`
+	Revenue      int    `json:"revenue"`
+	GrossSales   int    `json:"gross_sales"`
+	Discounts    int    `json:"discounts"`
+	ItemsSold    int    `json:"items_sold"`
+	RefundCount  int    `json:"refund_count"`
+	Refunds      int    `json:"refunds"`
+}
+
+// ItemRollup is the daily sales of one product or one category. Revenue
+// and Refunds are at line subtotal, before transaction discounts.
+type ItemRollup struct {
+	Date             string `json:"business_date"`
+	ID               int    `json:"id"`
+	Quantity         int    `json:"quantity"`
+	Revenue          int    `json:"revenue"`
+	RefundedQuantity int    `json:"refunded_quantity"`
+	Refunds          int    `json:"refunds"`
+}
+
+// PaymentRollup is the daily sales of one payment method
+type PaymentRollup struct {
+	Date          string `json:"business_date"`
+	PaymentMethod string `json:"payment_method"`
+	Transactions  int    `json:"transactions"`
+	Sales         int    `json:"sales"`
+	Refunds       int    `json:"refunds"`
+}
+
+// RollupMismatch is a rollup row that differs from the raw transactions
+type RollupMismatch struct {
+	Table    string `json:"table"`
+	Key      string `json:"key"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// RollupCheck is the result of comparing the rollups with a recomputation
+// from transactions
+type RollupCheck struct {
+	Consistent bool             `json:"consistent"`
+	Current    bool             `json:"current"`
+	CheckedAt  time.Time        `json:"checked_at"`
+	Days       int              `json:"days"`
+	Mismatches []RollupMismatch `json:"mismatches"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Shift statuses
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// Shift is a cashier session on the register, from opening float to the
+// final cash count
+type Shift struct {
+	ID           int        `json:"id"`
+	Status       string     `json:"status"`
+	OpenedBy     string     `json:"opened_by"`
+	OpeningFloat int        `json:"opening_float"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedBy     string     `json:"closed_by,omitempty"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	CountedCash  *int       `json:"counted_cash,omitempty"`
+	ZNumber      *int       `json:"z_number,omitempty"`
+	Note         string     `json:"note,omitempty"`
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat int `json:"opening_float"`
+	// OpenedBy is the signed in user, set by the handler
+	OpenedBy string `json:"-"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash int    `json:"counted_cash"`
+	Note        string `json:"note"`
+	// ClosedBy is the signed in user, set by the handler
+	ClosedBy string `json:"-"`
+}
+
+// PaymentMethodTotal is the sales, refunds and credit repayments taken
+// with one payment method
+type PaymentMethodTotal struct {
+	PaymentMethod string `json:"payment_method"`
+	Transactions  int    `json:"transactions"`
+	Sales         int    `json:"sales"`
+	Refunds       int    `json:"refunds"`
+	Repayments    int    `json:"repayments"`
+	Net           int    `json:"net"`
+}
+
+// ShiftReport is an X-report (snapshot of an open shift) or a Z-report
+// (final report of a closed shift). Z-reports are stored and never change.
+type ShiftReport struct {
+	Type          string               `json:"type"`
+	ZNumber       *int                 `json:"z_number,omitempty"`
+	ShiftID       int                  `json:"shift_id"`
+	OpenedBy      string               `json:"opened_by"`
+	ClosedBy      string               `json:"closed_by,omitempty"`
+	OpenedAt      time.Time            `json:"opened_at"`
+	ClosedAt      *time.Time           `json:"closed_at,omitempty"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	Transactions  int                  `json:"transactions"`
+	ItemsSold     int                  `json:"items_sold"`
+	GrossSales    int                  `json:"gross_sales"`
+	Discounts     int                  `json:"discounts"`
+	NetSales      int                  `json:"net_sales"`
+	RefundCount   int                  `json:"refund_count"`
+	Refunds       int                  `json:"refunds"`
+	Repayments    int                  `json:"repayments"`
+	PaymentTotals []PaymentMethodTotal `json:"payment_totals"`
+	OpeningFloat  int                  `json:"opening_float"`
+	ExpectedCash  int                  `json:"expected_cash"`
+	CountedCash   *int                 `json:"counted_cash,omitempty"`
+	Variance      *int                 `json:"variance,omitempty"`
+	Note          string               `json:"note,omitempty"`
+}
@@ -2,11 +2,45 @@ package models
 
 import "time"
 
+// Payment methods accepted at checkout
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentQRIS     = "qris"
+	PaymentTransfer = "transfer"
+	PaymentCredit   = "credit" // kasbon, paid later against the customer's account
+)
+
+// PaymentMethods lists the valid payment methods
+var PaymentMethods = []string{PaymentCash, PaymentCard, PaymentQRIS, PaymentTransfer, PaymentCredit}
+
+// IsValidPaymentMethod reports whether method is one of PaymentMethods
+func IsValidPaymentMethod(method string) bool {
+	for _, m := range PaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Transaction is a completed sale. TotalAmount is what the customer paid,
+// i.e. the sum of the detail subtotals minus DiscountAmount.
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID             int                 `json:"id"`
+	TotalAmount    int                 `json:"total_amount"`
+	DiscountAmount int                 `json:"discount_amount"`
+	PaymentMethod  string              `json:"payment_method"`
+	ShiftID        *int                `json:"shift_id"`
+	CustomerID     *int                `json:"customer_id"`
+	UserID         *int                `json:"user_id"`
+	ApprovedBy     *int                `json:"approved_by"`
+	Refunded       bool                `json:"refunded"`
+	CreatedAt      time.Time           `json:"created_at"`
+	BusinessDate   string              `json:"business_date"`
+	Details        []TransactionDetail `json:"details"`
+	Voucher        *VoucherRedemption  `json:"voucher,omitempty"`
+	Loyalty        *LoyaltyCheckout    `json:"loyalty,omitempty"`
 }
 
 type TransactionDetail struct {
@@ -21,8 +55,42 @@ type TransactionDetail struct {
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
 	Quantity  int `json:"quantity"`
+	// Price overrides the product price for this sale; needs the
+	// price:override permission or a supervisor override
+	Price *int `json:"price,omitempty"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items         []CheckoutItem `json:"items"`
+	PaymentMethod string         `json:"payment_method"`
+	// Discount is a manual discount; like a price override it needs the
+	// price:override permission or a supervisor override
+	Discount     int    `json:"discount"`
+	CustomerID   *int   `json:"customer_id,omitempty"`
+	RedeemPoints int    `json:"redeem_points"`
+	VoucherCode  string `json:"voucher_code,omitempty"`
+	// UserID is the signed in cashier and ApprovedBy the supervisor who
+	// approved an override, set by the handler
+	UserID     *int `json:"-"`
+	ApprovedBy *int `json:"-"`
+}
+
+// Refund reverses a whole transaction and puts its items back in stock
+type Refund struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	Amount        int       `json:"amount"`
+	PaymentMethod string    `json:"payment_method"`
+	Reason        string    `json:"reason"`
+	ShiftID       *int      `json:"shift_id"`
+	UserID        *int      `json:"user_id"`
+	ApprovedBy    *int      `json:"approved_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type RefundRequest struct {
+	Reason string `json:"reason"`
+	// set by the handler, like CheckoutRequest.UserID and ApprovedBy
+	UserID     *int `json:"-"`
+	ApprovedBy *int `json:"-"`
 }
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// User is a staff account that can sign in to the API. Role decides what
+// they may do; HasPIN tells whether they can approve supervisor overrides.
+type User struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	HasPIN      bool      `json:"has_pin"`
+	Active      bool      `json:"active"`
+	Permissions []string  `json:"permissions,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserRequest creates or changes a user. Password and PIN are only set
+// when given.
+type UserRequest struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"`
+	PIN      string `json:"pin,omitempty"`
+	Active   *bool  `json:"active,omitempty"`
+}
+
+// PasswordChangeRequest changes the password of the signed in user
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// LoginRequest signs a user in
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest trades a refresh token for new tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Session is one sign-in of a user
+type Session struct {
+	ID        int
+	UserID    int
+	ExpiresAt time.Time
+}
+
+// TokenResponse is returned by login and refresh. Send the access token
+// as "Authorization: Bearer <token>"; use the refresh token to get a new
+// pair before the access token expires.
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Voucher value types
+const (
+	VoucherFixed   = "fixed"
+	VoucherPercent = "percent"
+)
+
+// Voucher is a coupon code that takes money off a checkout. Value is
+// Rupiah for fixed vouchers and whole percent for percent vouchers.
+// Restricted to products or categories, the voucher only discounts those
+// lines and MinSpend counts only those lines. 0 limits mean unlimited.
+type Voucher struct {
+	ID               int       `json:"id"`
+	Code             string    `json:"code"`
+	Description      string    `json:"description"`
+	Type             string    `json:"type"`
+	Value            int       `json:"value"`
+	MaxDiscount      int       `json:"max_discount"`
+	MinSpend         int       `json:"min_spend"`
+	ValidFrom        *string   `json:"valid_from"`
+	ValidUntil       *string   `json:"valid_until"`
+	UsageLimit       int       `json:"usage_limit"`
+	PerCustomerLimit int       `json:"per_customer_limit"`
+	TimesUsed        int       `json:"times_used"`
+	Active           bool      `json:"active"`
+	ProductIDs       []int     `json:"product_ids"`
+	CategoryIDs      []int     `json:"category_ids"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// VoucherRedemption is one use of a voucher. A refund reverses it and
+// gives the use back.
+type VoucherRedemption struct {
+	ID            int        `json:"id"`
+	VoucherID     int        `json:"voucher_id"`
+	Code          string     `json:"code"`
+	TransactionID int        `json:"transaction_id"`
+	CustomerID    *int       `json:"customer_id"`
+	Amount        int        `json:"amount"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReversedAt    *time.Time `json:"reversed_at"`
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// APIKeyRepository handles database operations for API keys
+type APIKeyRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository
+func NewAPIKeyRepository(db *sql.DB, calendar *clock.Calendar) *APIKeyRepository {
+	return &APIKeyRepository{db: db, calendar: calendar}
+}
+
+const apiKeyColumns = "id, name, prefix, created_by, expires_at, last_used_at, revoked_at, created_at"
+
+// lastUsedEvery is how stale last_used_at may get, so a busy key is not
+// written on every request
+const lastUsedEvery = time.Minute
+
+// =======================
+// GET ALL API KEYS
+// =======================
+func (repo *APIKeyRepository) GetAll() ([]models.APIKey, error) {
+	rows, err := repo.db.Query("SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := repo.scanAPIKey(rows, &k); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		if err := loadAPIKeyScopes(repo.db, &keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// =======================
+// CREATE API KEY
+// =======================
+func (repo *APIKeyRepository) Create(key *models.APIKey, keyHash string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	var expiresAt interface{}
+	if key.ExpiresAt != nil {
+		expiresAt = clock.ToDB(*key.ExpiresAt)
+	}
+	res, err := tx.Exec(`
+		INSERT INTO api_keys (name, prefix, key_hash, created_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, key.Name, key.Prefix, keyHash, key.CreatedBy, expiresAt, clock.ToDB(createdAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range key.Scopes {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO api_key_scopes (api_key_id, permission) VALUES (?, ?)", id, scope,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	key.ID = int(id)
+	key.CreatedAt = createdAt.In(repo.calendar.Location)
+	if key.ExpiresAt != nil {
+		expires := key.ExpiresAt.In(repo.calendar.Location)
+		key.ExpiresAt = &expires
+	}
+	return nil
+}
+
+// =======================
+// GET API KEY BY ID
+// =======================
+func (repo *APIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	var k models.APIKey
+	err := repo.scanAPIKey(repo.db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id), &k)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("apikey.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadAPIKeyScopes(repo.db, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// =======================
+// ROTATE API KEY
+// =======================
+// Rotate replaces the secret of a live key; the old secret stops working
+// straight away
+func (repo *APIKeyRepository) Rotate(id int, prefix, keyHash string) error {
+	res, err := repo.db.Exec(
+		"UPDATE api_keys SET prefix = ?, key_hash = ? WHERE id = ? AND revoked_at IS NULL",
+		prefix, keyHash, id,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("apikey.not_found_or_revoked")
+	}
+	return nil
+}
+
+// =======================
+// REVOKE API KEY
+// =======================
+func (repo *APIKeyRepository) Revoke(id int) error {
+	res, err := repo.db.Exec(
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		clock.ToDB(time.Now().UTC().Truncate(time.Second)), id,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("apikey.not_found_or_revoked")
+	}
+	return nil
+}
+
+// =======================
+// AUTHENTICATE API KEY
+// =======================
+// Authenticate returns the live key with the given hash, not revoked or
+// expired, and records that it was used
+func (repo *APIKeyRepository) Authenticate(keyHash string) (*models.APIKey, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var k models.APIKey
+	err := repo.scanAPIKey(repo.db.QueryRow(`
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE key_hash = ? AND revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > ?)
+	`, keyHash, clock.ToDB(now)), &k)
+	if err == sql.ErrNoRows {
+		return nil, apperror.Unauthorized("auth.invalid_api_key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadAPIKeyScopes(repo.db, &k); err != nil {
+		return nil, err
+	}
+
+	_, err = repo.db.Exec(`
+		UPDATE api_keys SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, clock.ToDB(now), k.ID, clock.ToDB(now.Add(-lastUsedEvery)))
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (repo *APIKeyRepository) scanAPIKey(row rowScanner, k *models.APIKey) error {
+	var createdBy sql.NullInt64
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &createdBy, &expiresAt, &lastUsedAt, &revokedAt, &k.CreatedAt)
+	if err != nil {
+		return err
+	}
+	k.CreatedBy = nullIntPtr(createdBy)
+	k.ExpiresAt = repo.nullTimePtr(expiresAt)
+	k.LastUsedAt = repo.nullTimePtr(lastUsedAt)
+	k.RevokedAt = repo.nullTimePtr(revokedAt)
+	k.CreatedAt = k.CreatedAt.In(repo.calendar.Location)
+	return nil
+}
+
+func (repo *APIKeyRepository) nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time.In(repo.calendar.Location)
+	return &t
+}
+
+func loadAPIKeyScopes(q queryer, k *models.APIKey) error {
+	scopes, err := q.Query("SELECT permission FROM api_key_scopes WHERE api_key_id = ? ORDER BY permission", k.ID)
+	if err != nil {
+		return err
+	}
+	defer scopes.Close()
+
+	k.Scopes = []string{}
+	for scopes.Next() {
+		var s string
+		if err := scopes.Scan(&s); err != nil {
+			return err
+		}
+		k.Scopes = append(k.Scopes, s)
+	}
+	return scopes.Err()
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// AuditRepository handles database operations for the audit log
+type AuditRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewAuditRepository creates a new instance of AuditRepository
+func NewAuditRepository(db *sql.DB, calendar *clock.Calendar) *AuditRepository {
+	return &AuditRepository{db: db, calendar: calendar}
+}
+
+// AuditFunc records the audit entry of a change to entity id, after is its
+// new state or nil once deleted. Repositories call it with the database
+// transaction making the change, so neither is saved without the other.
+type AuditFunc func(tx *sql.Tx, id int, after interface{}) error
+
+// =======================
+// CREATE AUDIT ENTRY
+// =======================
+// Create writes entry within tx, the transaction of the audited change
+func (repo *AuditRepository) Create(tx *sql.Tx, entry *models.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.Exec(`
+		INSERT INTO audit_log (actor_type, actor_id, actor_name, action, entity_type, entity_id, changes, request_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ActorType, entry.ActorID, entry.ActorName, entry.Action, entry.EntityType, entry.EntityID,
+		string(changes), entry.RequestID, clock.ToDB(createdAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	entry.CreatedAt = createdAt.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// GET AUDIT ENTRIES
+// =======================
+// GetAll returns the entries matching filter, newest first
+func (repo *AuditRepository) GetAll(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var where []string
+	var args []interface{}
+	if filter.EntityType != "" {
+		where = append(where, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		where = append(where, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.ActorType != "" {
+		where = append(where, "actor_type = ?")
+		args = append(args, filter.ActorType)
+	}
+	if filter.ActorID != 0 {
+		where = append(where, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.RequestID != "" {
+		where = append(where, "request_id = ?")
+		args = append(args, filter.RequestID)
+	}
+	if !filter.StartDate.IsZero() {
+		from, _ := repo.calendar.Bounds(filter.StartDate, filter.StartDate)
+		where = append(where, "created_at >= ?")
+		args = append(args, clock.ToDB(from))
+	}
+	if !filter.EndDate.IsZero() {
+		_, to := repo.calendar.Bounds(filter.EndDate, filter.EndDate)
+		where = append(where, "created_at < ?")
+		args = append(args, clock.ToDB(to))
+	}
+
+	query := `
+		SELECT id, actor_type, actor_id, actor_name, action, entity_type, entity_id, changes, request_id, created_at
+		FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var actorID sql.NullInt64
+		var changes string
+		err := rows.Scan(&e.ID, &e.ActorType, &actorID, &e.ActorName, &e.Action,
+			&e.EntityType, &e.EntityID, &changes, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, err
+		}
+		e.ActorID = nullIntPtr(actorID)
+		e.CreatedAt = e.CreatedAt.In(repo.calendar.Location)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,526 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// CartRepository handles parked carts. Reservations are not stored on
+// the products: reservedStock sums the lines of open, unexpired carts, so
+// a cart that expires or is closed releases its stock on its own.
+type CartRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewCartRepository creates a new instance of CartRepository
+func NewCartRepository(db *sql.DB, calendar *clock.Calendar) *CartRepository {
+	return &CartRepository{db: db, calendar: calendar}
+}
+
+const cartColumns = `
+	id, label, customer_id, reserve, status, ttl_minutes, transaction_id,
+	expires_at, created_at, updated_at`
+
+// =======================
+// GET OPEN CARTS
+// =======================
+// GetOpen lists the parked carts, oldest first
+func (repo *CartRepository) GetOpen() ([]models.Cart, error) {
+	if _, err := repo.ExpireAll(); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(
+		"SELECT "+cartColumns+" FROM carts WHERE status = ? ORDER BY created_at, id",
+		models.CartOpen,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	carts := []models.Cart{}
+	for rows.Next() {
+		var c models.Cart
+		if err := repo.scanCart(rows, &c); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range carts {
+		if err := loadCartItems(repo.db, &carts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return carts, nil
+}
+
+// =======================
+// CREATE CART
+// =======================
+func (repo *CartRepository) Create(req models.CartRequest) (*models.Cart, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if req.CustomerID != nil {
+		if err := customerExists(tx, *req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(time.Duration(req.TTLMinutes) * time.Minute)
+	res, err := tx.Exec(`
+		INSERT INTO carts (label, customer_id, reserve, status, ttl_minutes, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Label, req.CustomerID, req.Reserve, models.CartOpen, req.TTLMinutes,
+		clock.ToDB(expiresAt), clock.ToDB(now), clock.ToDB(now))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		if err := setCartItem(tx, int(id), req.Reserve, item.ProductID, item.Quantity, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(int(id))
+}
+
+// =======================
+// GET CART BY ID
+// =======================
+// GetByID returns a cart with its lines. An open cart past its expiry
+// reads as expired even before ExpireAll has closed it.
+func (repo *CartRepository) GetByID(id int) (*models.Cart, error) {
+	var c models.Cart
+	err := repo.scanCart(repo.db.QueryRow("SELECT "+cartColumns+" FROM carts WHERE id = ?", id), &c)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("cart.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Status == models.CartOpen && !c.ExpiresAt.After(time.Now()) {
+		c.Status = models.CartExpired
+	}
+
+	if err := loadCartItems(repo.db, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// =======================
+// UPDATE CART
+// =======================
+// Update changes the label, customer, reservation and time to live of an
+// open cart. Turning reservation on checks every line is still in stock.
+func (repo *CartRepository) Update(cart *models.Cart) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := openCart(tx, cart.ID); err != nil {
+		return err
+	}
+
+	if cart.CustomerID != nil {
+		if err := customerExists(tx, *cart.CustomerID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE carts SET label = ?, customer_id = ?, reserve = ?, ttl_minutes = ?
+		WHERE id = ?
+	`, cart.Label, cart.CustomerID, cart.Reserve, cart.TTLMinutes, cart.ID)
+	if err != nil {
+		return err
+	}
+
+	if cart.Reserve {
+		rows, err := tx.Query("SELECT product_id, quantity FROM cart_items WHERE cart_id = ?", cart.ID)
+		if err != nil {
+			return err
+		}
+		lines := map[int]int{}
+		for rows.Next() {
+			var productID, quantity int
+			if err := rows.Scan(&productID, &quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			lines[productID] = quantity
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for productID, quantity := range lines {
+			if err := checkReservable(tx, cart.ID, productID, quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := touchCart(tx, cart.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================
+// SET CART ITEM
+// =======================
+// SetItem puts quantity of a product in an open cart. With add set the
+// quantity is added to what the cart already holds.
+func (repo *CartRepository) SetItem(cartID, productID, quantity int, add bool) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cart, err := openCart(tx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if err := setCartItem(tx, cartID, cart.Reserve, productID, quantity, add); err != nil {
+		return err
+	}
+
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================
+// REMOVE CART ITEM
+// =======================
+func (repo *CartRepository) RemoveItem(cartID, productID int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := openCart(tx, cartID); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM cart_items WHERE cart_id = ? AND product_id = ?", cartID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("cart.item_not_found")
+	}
+
+	if err := touchCart(tx, cartID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================
+// CANCEL CART
+// =======================
+// Cancel closes an open cart and releases its reservations
+func (repo *CartRepository) Cancel(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := openCart(tx, id); err != nil {
+		return err
+	}
+
+	if err := closeCart(tx, id, models.CartCancelled, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================
+// CHECKOUT CART
+// =======================
+// Checkout sells the contents of an open cart through the normal checkout
+// and closes the cart, in one database transaction. The cart's own
+// reservations count as available stock for the sale.
+func (repo *CartRepository) Checkout(id int, req models.CheckoutRequest, audit AuditFunc) (*models.Transaction, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cart, err := openCart(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT product_id, quantity FROM cart_items WHERE cart_id = ? ORDER BY rowid", id)
+	if err != nil {
+		return nil, err
+	}
+	req.Items = []models.CheckoutItem{}
+	for rows.Next() {
+		var item models.CheckoutItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		req.Items = append(req.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(req.Items) == 0 {
+		return nil, apperror.Conflict("cart.empty")
+	}
+
+	if req.CustomerID == nil {
+		req.CustomerID = cart.CustomerID
+	}
+
+	transaction, err := createTransaction(tx, repo.calendar, req, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := closeCart(tx, id, models.CartConverted, &transaction.ID); err != nil {
+		return nil, err
+	}
+
+	if err := audit(tx, transaction.ID, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// =======================
+// EXPIRE CARTS
+// =======================
+// ExpireAll closes every open cart past its expiry, releasing what it
+// reserved, and returns how many were closed
+func (repo *CartRepository) ExpireAll() (int, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := repo.db.Exec(`
+		UPDATE carts SET status = ?, updated_at = ?
+		WHERE status = ? AND expires_at <= ?
+	`, models.CartExpired, clock.ToDB(now), models.CartOpen, clock.ToDB(now))
+	if err != nil {
+		return 0, err
+	}
+
+	expired, err := res.RowsAffected()
+	return int(expired), err
+}
+
+func (repo *CartRepository) scanCart(row rowScanner, c *models.Cart) error {
+	var customerID, transactionID sql.NullInt64
+	err := row.Scan(
+		&c.ID, &c.Label, &customerID, &c.Reserve, &c.Status, &c.TTLMinutes, &transactionID,
+		&c.ExpiresAt, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	c.CustomerID = nullIntPtr(customerID)
+	c.TransactionID = nullIntPtr(transactionID)
+	c.ExpiresAt = c.ExpiresAt.In(repo.calendar.Location)
+	c.CreatedAt = c.CreatedAt.In(repo.calendar.Location)
+	c.UpdatedAt = c.UpdatedAt.In(repo.calendar.Location)
+	return nil
+}
+
+// loadCartItems fills in the lines and total of a cart at current prices
+func loadCartItems(q queryer, c *models.Cart) error {
+	rows, err := q.Query(`
+		SELECT ci.product_id, p.name, p.price, ci.quantity
+		FROM cart_items ci
+		JOIN products p ON p.id = ci.product_id
+		WHERE ci.cart_id = ?
+		ORDER BY ci.rowid
+	`, c.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	c.Items = []models.CartItem{}
+	c.Total = 0
+	for rows.Next() {
+		var item models.CartItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Price, &item.Quantity); err != nil {
+			return err
+		}
+		item.Subtotal = item.Price * item.Quantity
+		c.Total += item.Subtotal
+		c.Items = append(c.Items, item)
+	}
+	return rows.Err()
+}
+
+// openCart loads a cart that can still be changed: open and not expired
+func openCart(tx *sql.Tx, id int) (*models.Cart, error) {
+	var c models.Cart
+	var customerID sql.NullInt64
+	var expiresAt time.Time
+	err := tx.QueryRow(
+		"SELECT id, customer_id, reserve, status, expires_at FROM carts WHERE id = ?", id,
+	).Scan(&c.ID, &customerID, &c.Reserve, &c.Status, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("cart.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.CustomerID = nullIntPtr(customerID)
+
+	if c.Status == models.CartOpen && !expiresAt.After(time.Now()) {
+		if err := closeCart(tx, id, models.CartExpired, nil); err != nil {
+			return nil, err
+		}
+		// keep the cart closed even though the caller's change fails
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		c.Status = models.CartExpired
+	}
+	if c.Status != models.CartOpen {
+		return nil, apperror.Conflict("cart.not_open", c.Status)
+	}
+	return &c, nil
+}
+
+// setCartItem validates a product and quantity and writes the cart line
+func setCartItem(tx *sql.Tx, cartID int, reserve bool, productID, quantity int, add bool) error {
+	if quantity <= 0 {
+		return validate.Field("quantity", validate.NotPositive, "field.not_positive", "quantity")
+	}
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", productID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return apperror.NotFound("product.id_not_found", productID).With("product_id", productID)
+	}
+
+	if add {
+		var current int
+		err := tx.QueryRow(
+			"SELECT quantity FROM cart_items WHERE cart_id = ? AND product_id = ?", cartID, productID,
+		).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		quantity += current
+	}
+
+	if reserve {
+		if err := checkReservable(tx, cartID, productID, quantity); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO cart_items (cart_id, product_id, quantity) VALUES (?, ?, ?)
+		ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = excluded.quantity
+	`, cartID, productID, quantity)
+	return err
+}
+
+// checkReservable refuses to reserve more than the stock other carts
+// have not reserved already
+func checkReservable(tx *sql.Tx, cartID, productID, quantity int) error {
+	var name string
+	var stock int
+	if err := tx.QueryRow("SELECT name, stock FROM products WHERE id = ?", productID).Scan(&name, &stock); err != nil {
+		return err
+	}
+
+	reserved, err := reservedStock(tx, productID, cartID)
+	if err != nil {
+		return err
+	}
+	if stock-reserved < quantity {
+		return apperror.InsufficientStock(productID, name, stock-reserved, quantity)
+	}
+	return nil
+}
+
+// touchCart pushes the expiry of a cart out by its time to live
+func touchCart(tx *sql.Tx, id int) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := tx.Exec(`
+		UPDATE carts SET updated_at = ?, expires_at = datetime(?, '+' || ttl_minutes || ' minutes')
+		WHERE id = ?
+	`, clock.ToDB(now), clock.ToDB(now), id)
+	return err
+}
+
+// closeCart sets the final status of a cart
+func closeCart(tx *sql.Tx, id int, status string, transactionID *int) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := tx.Exec(
+		"UPDATE carts SET status = ?, transaction_id = ?, updated_at = ? WHERE id = ?",
+		status, transactionID, clock.ToDB(now), id,
+	)
+	return err
+}
+
+// reservedStock is how much of a product open, unexpired carts other
+// than exceptCart hold back
+func reservedStock(q queryer, productID, exceptCart int) (int, error) {
+	var reserved int
+	err := q.QueryRow(`
+		SELECT IFNULL(SUM(ci.quantity), 0)
+		FROM cart_items ci
+		JOIN carts c ON c.id = ci.cart_id
+		WHERE ci.product_id = ? AND c.id <> ?
+		AND c.status = ? AND c.reserve = 1 AND c.expires_at > ?
+	`, productID, exceptCart, models.CartOpen, clock.ToDB(time.Now().UTC())).Scan(&reserved)
+	return reserved, err
+}
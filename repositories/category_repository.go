@@ -2,7 +2,7 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"task-crud-kategori/apperror"
 	"task-crud-kategori/models"
 )
 
@@ -51,11 +51,17 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 // =======================
 // CREATE CATEGORY
 // =======================
-func (repo *CategoryRepository) Create(category *models.Category) error {
+func (repo *CategoryRepository) Create(category *models.Category, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Query sqlite to Insert new category into database
 	query := "INSERT INTO categories (name, description) VALUES (?, ?)"
 	// Execute the query
-	result, err := repo.db.Exec(
+	result, err := tx.Exec(
 		query,
 		category.Name,
 		category.Description,
@@ -71,7 +77,11 @@ func (repo *CategoryRepository) Create(category *models.Category) error {
 	}
 	// Set the ID to the category model
 	category.ID = int(id)
-	return nil
+
+	if err := audit(tx, category.ID, category); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // =======================
@@ -90,7 +100,7 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	)
 	// Handle error
 	if err == sql.ErrNoRows {
-		return nil, errors.New("kategori tidak ditemukan")
+		return nil, apperror.NotFound("category.not_found")
 	}
 	if err != nil {
 		return nil, err
@@ -102,7 +112,13 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 // =======================
 // UPDATE CATEGORY
 // =======================
-func (repo *CategoryRepository) Update(category *models.Category) error {
+func (repo *CategoryRepository) Update(category *models.Category, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Query sqlite to update category
 	query := `
 		UPDATE categories
@@ -110,7 +126,7 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 		WHERE id = ?
 	`
 	// Execute the query
-	result, err := repo.db.Exec(
+	result, err := tx.Exec(
 		query,
 		category.Name,
 		category.Description,
@@ -125,21 +141,39 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 		return err
 	}
 	if rows == 0 {
-		return errors.New("kategori tidak ditemukan")
+		return apperror.NotFound("category.not_found")
 	}
 
-	// Return nil if successful
-	return nil
+	if err := audit(tx, category.ID, category); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // =======================
 // DELETE CATEGORY
 // =======================
-func (repo *CategoryRepository) Delete(id int) error {
+func (repo *CategoryRepository) Delete(id int, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// products cannot be left without a category
+	var products int
+	err = tx.QueryRow("SELECT COUNT(*) FROM products WHERE category_id = ?", id).Scan(&products)
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return apperror.Conflict("category.has_products", products).With("products", products)
+	}
+
 	// Query sqlite to delete category by ID
 	query := "DELETE FROM categories WHERE id = ?"
 	// Execute the query
-	result, err := repo.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	// Handle error
 	if err != nil {
 		return err
@@ -149,8 +183,11 @@ func (repo *CategoryRepository) Delete(id int) error {
 		return err
 	}
 	if rows == 0 {
-		return errors.New("kategori tidak ditemukan")
+		return apperror.NotFound("category.not_found")
+	}
+
+	if err := audit(tx, id, nil); err != nil {
+		return err
 	}
-	// Return nil if successful
-	return nil
+	return tx.Commit()
 }
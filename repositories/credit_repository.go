@@ -0,0 +1,279 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// CreditRepository handles customer credit (kasbon): what customers owe
+// for sales paid with the credit method and what they paid back
+type CreditRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewCreditRepository creates a new instance of CreditRepository
+func NewCreditRepository(db *sql.DB, calendar *clock.Calendar) *CreditRepository {
+	return &CreditRepository{db: db, calendar: calendar}
+}
+
+// =======================
+// GET CREDIT ACCOUNT
+// =======================
+// GetAccount returns the balance, open credit sales and repayments of a
+// customer. Ages are counted in business days up to today.
+func (repo *CreditRepository) GetAccount(customerID int) (*models.CreditAccount, error) {
+	account := &models.CreditAccount{CustomerID: customerID}
+	err := repo.db.QueryRow(
+		"SELECT name, credit_limit FROM customers WHERE id = ?", customerID,
+	).Scan(&account.Name, &account.CreditLimit)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := creditBalance(repo.db, customerID)
+	if err != nil {
+		return nil, err
+	}
+	account.Balance = max(0, balance)
+	account.StoreCredit = max(0, -balance)
+	account.Available = max(0, account.CreditLimit-account.Balance)
+
+	invoices, err := repo.GetOpenInvoices(customerID, repo.calendar.Today())
+	if err != nil {
+		return nil, err
+	}
+	account.OpenInvoices = invoices[customerID]
+	if account.OpenInvoices == nil {
+		account.OpenInvoices = []models.CreditInvoice{}
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT id, customer_id, amount, payment_method, shift_id, note, created_at
+		FROM customer_repayments
+		WHERE customer_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	account.Repayments = []models.Repayment{}
+	for rows.Next() {
+		var r models.Repayment
+		var shiftID sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.CustomerID, &r.Amount, &r.PaymentMethod, &shiftID, &r.Note, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.ShiftID = nullIntPtr(shiftID)
+		r.CreatedAt = r.CreatedAt.In(repo.calendar.Location)
+		account.Repayments = append(account.Repayments, r)
+	}
+
+	return account, rows.Err()
+}
+
+// =======================
+// REPAY
+// =======================
+// Repay books money a customer pays towards their balance on the open
+// register shift. Paying more than is owed is refused.
+func (repo *CreditRepository) Repay(customerID int, req models.RepaymentRequest) (*models.Repayment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := customerExists(tx, customerID); err != nil {
+		return nil, err
+	}
+
+	balance, err := creditBalance(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if balance = max(0, balance); req.Amount > balance {
+		return nil, validate.Field("amount", validate.OutOfRange, "credit.repayment_exceeds", balance).
+			With("balance", balance)
+	}
+
+	shiftID, err := openShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.Exec(`
+		INSERT INTO customer_repayments (customer_id, amount, payment_method, shift_id, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, customerID, req.Amount, req.PaymentMethod, shiftID, req.Note, clock.ToDB(createdAt))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.Repayment{
+		ID:            int(id),
+		CustomerID:    customerID,
+		Amount:        req.Amount,
+		PaymentMethod: req.PaymentMethod,
+		ShiftID:       shiftID,
+		Note:          req.Note,
+		CreatedAt:     createdAt.In(repo.calendar.Location),
+	}, nil
+}
+
+// =======================
+// GET OPEN INVOICES
+// =======================
+// GetOpenInvoices returns the credit sales not yet repaid, per customer,
+// for one customer or everyone when customerID is 0. Repayments settle
+// the oldest sales first; refunded sales are not owed.
+func (repo *CreditRepository) GetOpenInvoices(customerID int, asOf time.Time) (map[int][]models.CreditInvoice, error) {
+	filter := ""
+	args := []interface{}{models.PaymentCredit}
+	if customerID != 0 {
+		filter = "AND t.customer_id = ?"
+		args = append(args, customerID)
+	}
+
+	repaid := map[int]int{}
+	rows, err := repo.db.Query(`
+		SELECT customer_id, SUM(amount)
+		FROM customer_repayments
+		GROUP BY customer_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, amount int
+		if err := rows.Scan(&id, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		repaid[id] = amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = repo.db.Query(`
+		SELECT t.id, t.customer_id, t.total_amount, t.created_at
+		FROM transactions t
+		WHERE t.payment_method = ? AND t.customer_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM refunds r WHERE r.transaction_id = t.id)
+		`+filter+`
+		ORDER BY t.customer_id, t.created_at, t.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := map[int][]models.CreditInvoice{}
+	for rows.Next() {
+		var inv models.CreditInvoice
+		var owner int
+		var createdAt time.Time
+		if err := rows.Scan(&inv.TransactionID, &owner, &inv.Amount, &createdAt); err != nil {
+			return nil, err
+		}
+
+		inv.Paid = min(inv.Amount, repaid[owner])
+		repaid[owner] -= inv.Paid
+		inv.Outstanding = inv.Amount - inv.Paid
+		if inv.Outstanding == 0 {
+			continue
+		}
+
+		date := repo.calendar.DateOf(createdAt)
+		inv.BusinessDate = date.Format(clock.DateLayout)
+		inv.AgeDays = clock.DaysBetween(date, asOf)
+		invoices[owner] = append(invoices[owner], inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// =======================
+// GET CREDIT CUSTOMERS
+// =======================
+// GetCustomers returns the name and credit limit of every customer
+func (repo *CreditRepository) GetCustomers() (map[int]models.CustomerAging, error) {
+	rows, err := repo.db.Query("SELECT id, name, credit_limit FROM customers")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := map[int]models.CustomerAging{}
+	for rows.Next() {
+		var c models.CustomerAging
+		if err := rows.Scan(&c.CustomerID, &c.Name, &c.CreditLimit); err != nil {
+			return nil, err
+		}
+		customers[c.CustomerID] = c
+	}
+	return customers, rows.Err()
+}
+
+// checkCreditLimit refuses a credit sale that would take the customer
+// over their credit limit. Store credit does not raise the limit.
+func checkCreditLimit(tx *sql.Tx, customerID, amount int) error {
+	var limit int
+	if err := tx.QueryRow("SELECT credit_limit FROM customers WHERE id = ?", customerID).Scan(&limit); err != nil {
+		return err
+	}
+	if limit == 0 {
+		return apperror.New(apperror.CodeCreditLimit, "credit.not_allowed").
+			With("limit", 0)
+	}
+
+	balance, err := creditBalance(tx, customerID)
+	if err != nil {
+		return err
+	}
+	if balance = max(0, balance); balance+amount > limit {
+		return apperror.New(apperror.CodeCreditLimit, "credit.limit_exceeded", max(0, limit-balance), limit).
+			With("available", max(0, limit-balance)).
+			With("limit", limit)
+	}
+	return nil
+}
+
+// creditBalance is what a customer owes: credit sales that were not
+// refunded, less repayments. It is negative when a refunded sale had
+// already been repaid; the difference is store credit, not extra limit.
+func creditBalance(q queryer, customerID int) (int, error) {
+	var balance int
+	err := q.QueryRow(`
+		SELECT
+			IFNULL((
+				SELECT SUM(t.total_amount)
+				FROM transactions t
+				WHERE t.customer_id = ? AND t.payment_method = ?
+				AND NOT EXISTS (SELECT 1 FROM refunds r WHERE r.transaction_id = t.id)
+			), 0)
+			- IFNULL((SELECT SUM(amount) FROM customer_repayments WHERE customer_id = ?), 0)
+	`, customerID, models.PaymentCredit, customerID).Scan(&balance)
+	return balance, err
+}
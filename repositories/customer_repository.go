@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// CustomerRepository handles database operations for customers
+type CustomerRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewCustomerRepository creates a new instance of CustomerRepository
+func NewCustomerRepository(db *sql.DB, calendar *clock.Calendar) *CustomerRepository {
+	return &CustomerRepository{db: db, calendar: calendar}
+}
+
+const customerColumns = "id, name, phone, email, notes, credit_limit, created_at"
+
+// =======================
+// GET ALL CUSTOMERS
+// =======================
+// GetAll lists customers; a non-empty search matches name, phone or email
+func (repo *CustomerRepository) GetAll(search string) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers"
+	args := []interface{}{}
+	if search != "" {
+		query += " WHERE name LIKE ? OR phone LIKE ? OR email LIKE ?"
+		pattern := "%" + search + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		var c models.Customer
+		if err := repo.scanCustomer(rows, &c); err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+
+	return customers, rows.Err()
+}
+
+// =======================
+// CREATE CUSTOMER
+// =======================
+func (repo *CustomerRepository) Create(customer *models.Customer) error {
+	if err := repo.checkPhone(customer.Phone, 0); err != nil {
+		return err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	result, err := repo.db.Exec(`
+		INSERT INTO customers (name, phone, email, notes, credit_limit, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, customer.Name, customer.Phone, customer.Email, customer.Notes, customer.CreditLimit, clock.ToDB(createdAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	customer.ID = int(id)
+	customer.CreatedAt = createdAt.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// GET CUSTOMER BY ID
+// =======================
+func (repo *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	var c models.Customer
+	err := repo.scanCustomer(repo.db.QueryRow("SELECT "+customerColumns+" FROM customers WHERE id = ?", id), &c)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// =======================
+// UPDATE CUSTOMER
+// =======================
+func (repo *CustomerRepository) Update(customer *models.Customer) error {
+	if err := repo.checkPhone(customer.Phone, customer.ID); err != nil {
+		return err
+	}
+
+	result, err := repo.db.Exec(`
+		UPDATE customers
+		SET name = ?, phone = ?, email = ?, notes = ?, credit_limit = ?
+		WHERE id = ?
+	`, customer.Name, customer.Phone, customer.Email, customer.Notes, customer.CreditLimit, customer.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("customer.not_found")
+	}
+
+	updated, err := repo.GetByID(customer.ID)
+	if err != nil {
+		return err
+	}
+	*customer = *updated
+	return nil
+}
+
+// =======================
+// DELETE CUSTOMER
+// =======================
+// Delete removes a customer without purchases; customers with a history
+// are kept so their transactions stay attributed
+func (repo *CustomerRepository) Delete(id int) error {
+	var purchases int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE customer_id = ?", id).Scan(&purchases)
+	if err != nil {
+		return err
+	}
+	if purchases > 0 {
+		return apperror.Conflict("customer.has_transactions")
+	}
+
+	result, err := repo.db.Exec("DELETE FROM customers WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("customer.not_found")
+	}
+	return nil
+}
+
+// =======================
+// GET LIFETIME VALUES
+// =======================
+// GetValues sums the purchases of every customer, or of one customer when
+// customerID is not 0. Items of refunded transactions are not counted.
+func (repo *CustomerRepository) GetValues(customerID int) ([]models.CustomerValue, error) {
+	filter := ""
+	args := []interface{}{}
+	if customerID != 0 {
+		filter = "WHERE c.id = ?"
+		args = append(args, customerID)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT
+			c.id,
+			c.name,
+			COUNT(t.id),
+			COUNT(r.id),
+			IFNULL(SUM(t.total_amount), 0),
+			IFNULL(SUM(r.amount), 0),
+			MIN(t.created_at),
+			MAX(t.created_at),
+			IFNULL((
+				SELECT SUM(td.quantity)
+				FROM transaction_details td
+				JOIN transactions t2 ON t2.id = td.transaction_id
+				WHERE t2.customer_id = c.id
+				AND NOT EXISTS (SELECT 1 FROM refunds r2 WHERE r2.transaction_id = t2.id)
+			), 0)
+		FROM customers c
+		LEFT JOIN transactions t ON t.customer_id = c.id
+		LEFT JOIN refunds r ON r.transaction_id = t.id
+		`+filter+`
+		GROUP BY c.id, c.name
+		ORDER BY c.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []models.CustomerValue{}
+	for rows.Next() {
+		var v models.CustomerValue
+		var first, last sql.NullString
+		err := rows.Scan(
+			&v.CustomerID,
+			&v.Name,
+			&v.Transactions,
+			&v.RefundedTransactions,
+			&v.GrossSpend,
+			&v.Refunds,
+			&first,
+			&last,
+			&v.ItemsBought,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// aggregates come back as text, not as DATETIME
+		if v.FirstPurchaseAt, err = repo.parseAggregateTime(first); err != nil {
+			return nil, err
+		}
+		if v.LastPurchaseAt, err = repo.parseAggregateTime(last); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	return values, rows.Err()
+}
+
+// checkPhone rejects a phone number already used by another customer
+func (repo *CustomerRepository) checkPhone(phone string, id int) error {
+	if phone == "" {
+		return nil
+	}
+
+	var taken int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM customers WHERE phone = ? AND id <> ?", phone, id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperror.Conflict("customer.phone_taken")
+	}
+	return nil
+}
+
+func (repo *CustomerRepository) parseAggregateTime(v sql.NullString) (*time.Time, error) {
+	if !v.Valid {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(clock.DBLayout, v.String, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	t = t.In(repo.calendar.Location)
+	return &t, nil
+}
+
+func (repo *CustomerRepository) scanCustomer(row rowScanner, c *models.Customer) error {
+	var createdAt sql.NullTime
+	err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Notes, &c.CreditLimit, &createdAt)
+	if err != nil {
+		return err
+	}
+	if createdAt.Valid {
+		c.CreatedAt = createdAt.Time.In(repo.calendar.Location)
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// EmployeeRepository handles database operations for employees and their
+// time clock
+type EmployeeRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewEmployeeRepository creates a new instance of EmployeeRepository
+func NewEmployeeRepository(db *sql.DB, calendar *clock.Calendar) *EmployeeRepository {
+	return &EmployeeRepository{db: db, calendar: calendar}
+}
+
+// employeeSelect reads an employee with the time entry they are clocked
+// in on, if any
+const employeeSelect = `
+	SELECT e.id, e.user_id, e.name, e.phone, e.position, e.hired_on, e.active,
+		e.created_at, e.updated_at, te.id, te.clock_in, te.note
+	FROM employees e
+	LEFT JOIN time_entries te ON te.employee_id = e.id AND te.clock_out IS NULL`
+
+// =======================
+// GET ALL EMPLOYEES
+// =======================
+// GetAll lists employees by name; inactive ones only when asked for
+func (repo *EmployeeRepository) GetAll(includeInactive bool) ([]models.Employee, error) {
+	query := employeeSelect
+	if !includeInactive {
+		query += " WHERE e.active = 1"
+	}
+	query += " ORDER BY e.name, e.id"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	employees := []models.Employee{}
+	for rows.Next() {
+		var e models.Employee
+		if err := repo.scanEmployee(rows, &e); err != nil {
+			return nil, err
+		}
+		employees = append(employees, e)
+	}
+
+	return employees, rows.Err()
+}
+
+// =======================
+// CREATE EMPLOYEE
+// =======================
+func (repo *EmployeeRepository) Create(employee *models.Employee) error {
+	if err := repo.checkUser(employee.UserID, 0); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := repo.db.Exec(`
+		INSERT INTO employees (user_id, name, phone, position, hired_on, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, employee.UserID, employee.Name, employee.Phone, employee.Position, employee.HiredOn,
+		employee.Active, clock.ToDB(now), clock.ToDB(now))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	employee.ID = int(id)
+	employee.CreatedAt = now.In(repo.calendar.Location)
+	employee.UpdatedAt = employee.CreatedAt
+	return nil
+}
+
+// =======================
+// GET EMPLOYEE BY ID
+// =======================
+func (repo *EmployeeRepository) GetByID(id int) (*models.Employee, error) {
+	var e models.Employee
+	err := repo.scanEmployee(repo.db.QueryRow(employeeSelect+" WHERE e.id = ?", id), &e)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("employee.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetByUserID returns the employee linked to a user account
+func (repo *EmployeeRepository) GetByUserID(userID int) (*models.Employee, error) {
+	var e models.Employee
+	err := repo.scanEmployee(repo.db.QueryRow(employeeSelect+" WHERE e.user_id = ?", userID), &e)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("employee.not_linked")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// =======================
+// UPDATE EMPLOYEE
+// =======================
+func (repo *EmployeeRepository) Update(employee *models.Employee) error {
+	if err := repo.checkUser(employee.UserID, employee.ID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := repo.db.Exec(`
+		UPDATE employees SET user_id = ?, name = ?, phone = ?, position = ?, hired_on = ?,
+			active = ?, updated_at = ?
+		WHERE id = ?
+	`, employee.UserID, employee.Name, employee.Phone, employee.Position, employee.HiredOn,
+		employee.Active, clock.ToDB(now), employee.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("employee.not_found")
+	}
+
+	employee.UpdatedAt = now.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// DELETE EMPLOYEE
+// =======================
+// Delete removes an employee who never clocked in; the others are kept
+// for their time records and should be deactivated instead
+func (repo *EmployeeRepository) Delete(id int) error {
+	var entries int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM time_entries WHERE employee_id = ?", id).Scan(&entries)
+	if err != nil {
+		return err
+	}
+	if entries > 0 {
+		return apperror.Conflict("employee.has_time_records")
+	}
+
+	result, err := repo.db.Exec("DELETE FROM employees WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("employee.not_found")
+	}
+	return nil
+}
+
+// =======================
+// CLOCK IN
+// =======================
+func (repo *EmployeeRepository) ClockIn(employeeID int, note string) (*models.TimeEntry, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var active bool
+	err = tx.QueryRow("SELECT active FROM employees WHERE id = ?", employeeID).Scan(&active)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("employee.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, apperror.Conflict("employee.inactive")
+	}
+
+	var open int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM time_entries WHERE employee_id = ? AND clock_out IS NULL", employeeID,
+	).Scan(&open)
+	if err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, apperror.Conflict("employee.clocked_in")
+	}
+
+	clockIn := time.Now().UTC().Truncate(time.Second)
+	result, err := tx.Exec(
+		"INSERT INTO time_entries (employee_id, clock_in, note) VALUES (?, ?, ?)",
+		employeeID, clock.ToDB(clockIn), note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.TimeEntry{
+		ID:         int(id),
+		EmployeeID: employeeID,
+		ClockIn:    clockIn.In(repo.calendar.Location),
+		Note:       note,
+	}, nil
+}
+
+// =======================
+// CLOCK OUT
+// =======================
+// ClockOut closes the open time entry of an employee. A note is added to
+// the one given at clock-in.
+func (repo *EmployeeRepository) ClockOut(employeeID int, note string) (*models.TimeEntry, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var entry models.TimeEntry
+	err = tx.QueryRow(`
+		SELECT id, employee_id, clock_in, note FROM time_entries
+		WHERE employee_id = ? AND clock_out IS NULL
+	`, employeeID).Scan(&entry.ID, &entry.EmployeeID, &entry.ClockIn, &entry.Note)
+	if err == sql.ErrNoRows {
+		return nil, apperror.Conflict("employee.not_clocked_in")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if note != "" {
+		if entry.Note != "" {
+			entry.Note += "\n"
+		}
+		entry.Note += note
+	}
+
+	clockOut := time.Now().UTC().Truncate(time.Second)
+	_, err = tx.Exec(
+		"UPDATE time_entries SET clock_out = ?, note = ? WHERE id = ?",
+		clock.ToDB(clockOut), entry.Note, entry.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	entry.ClockIn = entry.ClockIn.In(repo.calendar.Location)
+	out := clockOut.In(repo.calendar.Location)
+	entry.ClockOut = &out
+	entry.Minutes = workedMinutes(entry.ClockIn, entry.ClockOut)
+	return &entry, nil
+}
+
+// =======================
+// GET TIME ENTRIES
+// =======================
+// GetTimeEntries returns the entries of an employee clocked in during the
+// business days from startDate to endDate, oldest first
+func (repo *EmployeeRepository) GetTimeEntries(employeeID int, startDate, endDate time.Time) ([]models.TimeEntry, error) {
+	from, to := repo.calendar.Bounds(startDate, endDate)
+	rows, err := repo.db.Query(`
+		SELECT id, employee_id, clock_in, clock_out, note FROM time_entries
+		WHERE employee_id = ? AND clock_in >= ? AND clock_in < ?
+		ORDER BY clock_in, id
+	`, employeeID, clock.ToDB(from), clock.ToDB(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.TimeEntry{}
+	for rows.Next() {
+		var e models.TimeEntry
+		var clockOut sql.NullTime
+		if err := rows.Scan(&e.ID, &e.EmployeeID, &e.ClockIn, &clockOut, &e.Note); err != nil {
+			return nil, err
+		}
+		e.ClockIn = e.ClockIn.In(repo.calendar.Location)
+		if clockOut.Valid {
+			out := clockOut.Time.In(repo.calendar.Location)
+			e.ClockOut = &out
+		}
+		e.Minutes = workedMinutes(e.ClockIn, e.ClockOut)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// checkUser rejects a user account that does not exist or is already
+// linked to another employee
+func (repo *EmployeeRepository) checkUser(userID *int, id int) error {
+	if userID == nil {
+		return nil
+	}
+
+	var exists, linked int
+	err := repo.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE id = ?),
+			(SELECT COUNT(*) FROM employees WHERE user_id = ? AND id <> ?)
+	`, *userID, *userID, id).Scan(&exists, &linked)
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return apperror.NotFound("user.not_found")
+	}
+	if linked > 0 {
+		return apperror.Conflict("employee.user_linked")
+	}
+	return nil
+}
+
+func (repo *EmployeeRepository) scanEmployee(row rowScanner, e *models.Employee) error {
+	var userID, entryID sql.NullInt64
+	var clockIn sql.NullTime
+	var note sql.NullString
+	err := row.Scan(&e.ID, &userID, &e.Name, &e.Phone, &e.Position, &e.HiredOn, &e.Active,
+		&e.CreatedAt, &e.UpdatedAt, &entryID, &clockIn, &note)
+	if err != nil {
+		return err
+	}
+	e.UserID = nullIntPtr(userID)
+	e.CreatedAt = e.CreatedAt.In(repo.calendar.Location)
+	e.UpdatedAt = e.UpdatedAt.In(repo.calendar.Location)
+	if entryID.Valid {
+		e.ClockedIn = &models.TimeEntry{
+			ID:         int(entryID.Int64),
+			EmployeeID: e.ID,
+			ClockIn:    clockIn.Time.In(repo.calendar.Location),
+			Note:       note.String,
+		}
+		e.ClockedIn.Minutes = workedMinutes(e.ClockedIn.ClockIn, nil)
+	}
+	return nil
+}
+
+// workedMinutes is the length of a time entry, up to now while it is open
+func workedMinutes(clockIn time.Time, clockOut *time.Time) int {
+	end := time.Now()
+	if clockOut != nil {
+		end = *clockOut
+	}
+	return int(end.Sub(clockIn) / time.Minute)
+}
@@ -0,0 +1,683 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// LoyaltyRepository handles the loyalty program and the points ledger.
+// Points move at checkout and refund inside the transaction repository;
+// see startLoyalty and reverseLoyalty.
+type LoyaltyRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewLoyaltyRepository creates a new instance of LoyaltyRepository
+func NewLoyaltyRepository(db *sql.DB, calendar *clock.Calendar) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db, calendar: calendar}
+}
+
+// =======================
+// GET PROGRAM
+// =======================
+func (repo *LoyaltyRepository) GetProgram() (*models.LoyaltyProgram, error) {
+	return loadLoyaltyProgram(repo.db)
+}
+
+// =======================
+// UPDATE PROGRAM
+// =======================
+// UpdateProgram replaces the settings, rules and tiers. Points already
+// earned keep their expiry.
+func (repo *LoyaltyRepository) UpdateProgram(program *models.LoyaltyProgram) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"UPDATE loyalty_settings SET point_value = ?, expiry_days = ? WHERE id = 1",
+		program.PointValue, program.ExpiryDays,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM loyalty_rules"); err != nil {
+		return err
+	}
+	for _, rule := range program.Rules {
+		_, err := tx.Exec(
+			"INSERT INTO loyalty_rules (category_id, rupiah_per_point) VALUES (?, ?)",
+			rule.CategoryID, rule.RupiahPerPoint,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM loyalty_tiers"); err != nil {
+		return err
+	}
+	for _, tier := range program.Tiers {
+		_, err := tx.Exec(
+			"INSERT INTO loyalty_tiers (name, min_points, discount_percent) VALUES (?, ?, ?)",
+			tier.Name, tier.MinPoints, tier.DiscountPercent,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// =======================
+// GET ACCOUNT
+// =======================
+// GetAccount returns the points balance and tier of a customer, expiring
+// any points that are due first
+func (repo *LoyaltyRepository) GetAccount(customerID int) (*models.LoyaltyAccount, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := customerExists(tx, customerID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if _, _, err := expirePoints(tx, customerID, now); err != nil {
+		return nil, err
+	}
+
+	program, err := loadLoyaltyProgram(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.LoyaltyAccount{CustomerID: customerID, Expiring: []models.ExpiringPoints{}}
+	if account.Balance, err = pointsBalance(tx, customerID); err != nil {
+		return nil, err
+	}
+	if account.LifetimePoints, err = lifetimePoints(tx, customerID); err != nil {
+		return nil, err
+	}
+	account.BalanceValue = account.Balance * program.PointValue
+
+	tier, next := tierFor(program.Tiers, account.LifetimePoints)
+	if tier != nil {
+		account.Tier = tier.Name
+		account.TierDiscountPercent = tier.DiscountPercent
+	}
+	if next != nil {
+		missing := next.MinPoints - account.LifetimePoints
+		account.NextTier = next.Name
+		account.PointsToNextTier = &missing
+	}
+
+	rows, err := tx.Query(`
+		SELECT SUM(remaining), expires_at
+		FROM loyalty_ledger
+		WHERE customer_id = ? AND type = ? AND remaining > 0
+		GROUP BY expires_at
+		ORDER BY expires_at
+	`, customerID, models.PointsEarn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.ExpiringPoints
+		if err := rows.Scan(&e.Points, &e.ExpiresAt); err != nil {
+			return nil, err
+		}
+		e.ExpiresAt = e.ExpiresAt.In(repo.calendar.Location)
+		account.Expiring = append(account.Expiring, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// expired points are written to the ledger even on a read
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// =======================
+// GET LEDGER
+// =======================
+// GetLedger lists the points movements of a customer, newest first
+func (repo *LoyaltyRepository) GetLedger(customerID int) ([]models.LoyaltyEntry, error) {
+	if err := customerExists(repo.db, customerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT id, customer_id, type, points, remaining, expires_at, transaction_id, reference, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = ?
+		ORDER BY id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LoyaltyEntry{}
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		var remaining int
+		var expiresAt sql.NullTime
+		var transactionID sql.NullInt64
+		err := rows.Scan(
+			&e.ID,
+			&e.CustomerID,
+			&e.Type,
+			&e.Points,
+			&remaining,
+			&expiresAt,
+			&transactionID,
+			&e.Reference,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type == models.PointsEarn {
+			e.Remaining = &remaining
+		}
+		if expiresAt.Valid {
+			t := expiresAt.Time.In(repo.calendar.Location)
+			e.ExpiresAt = &t
+		}
+		e.TransactionID = nullIntPtr(transactionID)
+		e.CreatedAt = e.CreatedAt.In(repo.calendar.Location)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// =======================
+// EXPIRE POINTS
+// =======================
+// ExpireAll writes off every lot of points past its expiry date
+func (repo *LoyaltyRepository) ExpireAll() (*models.ExpireResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lots, points, err := expirePoints(tx, 0, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.ExpireResult{Lots: lots, Points: points}, nil
+}
+
+// loyaltyCheckout carries the loyalty side of a checkout from before the
+// transaction row is written to after it
+type loyaltyCheckout struct {
+	customerID int
+	program    *models.LoyaltyProgram
+	summary    models.LoyaltyCheckout
+}
+
+// startLoyalty works out the tier discount and checks a points redemption
+// for a customer buying gross worth of goods with a manual discount
+func startLoyalty(tx *sql.Tx, customerID, gross, discount, redeem int, at time.Time) (*loyaltyCheckout, error) {
+	if _, _, err := expirePoints(tx, customerID, at); err != nil {
+		return nil, err
+	}
+
+	program, err := loadLoyaltyProgram(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := &loyaltyCheckout{customerID: customerID, program: program}
+
+	lifetime, err := lifetimePoints(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if tier, _ := tierFor(program.Tiers, lifetime); tier != nil {
+		lc.summary.Tier = tier.Name
+		lc.summary.TierDiscountPercent = tier.DiscountPercent
+		lc.summary.TierDiscount = int(float64(gross-discount) * tier.DiscountPercent / 100)
+	}
+
+	if redeem > 0 {
+		balance, err := pointsBalance(tx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if redeem > balance {
+			return nil, apperror.New(apperror.CodeInsufficientPoints, "loyalty.points_balance", balance).
+				With("balance", balance).
+				With("requested", redeem)
+		}
+
+		lc.summary.PointsRedeemed = redeem
+		lc.summary.RedeemValue = redeem * program.PointValue
+		if lc.summary.RedeemValue > gross-discount-lc.summary.TierDiscount {
+			return nil, validate.Field("redeem_points", validate.OutOfRange, "loyalty.redeem_exceeds_due")
+		}
+	}
+
+	return lc, nil
+}
+
+// discount is what the loyalty program takes off the total
+func (lc *loyaltyCheckout) discount() int {
+	return lc.summary.TierDiscount + lc.summary.RedeemValue
+}
+
+// book writes the redemption and the earned points of a transaction.
+// Points are earned on what was actually paid, spread over the lines in
+// proportion to their subtotal, at the rule of each line's category.
+func (lc *loyaltyCheckout) book(tx *sql.Tx, transactionID int, details []models.TransactionDetail, categories map[int]int, paid int, at time.Time) error {
+	reference := fmt.Sprintf("transaction:%d", transactionID)
+
+	if lc.summary.PointsRedeemed > 0 {
+		if err := redeemPoints(tx, lc.customerID, transactionID, lc.summary.PointsRedeemed, reference, at); err != nil {
+			return err
+		}
+	}
+
+	gross := 0
+	for _, d := range details {
+		gross += d.Subtotal
+	}
+
+	earned := 0
+	if gross > 0 {
+		for _, d := range details {
+			rate := earnRate(lc.program, categories[d.ProductID])
+			if rate > 0 {
+				earned += d.Subtotal * paid / gross / rate
+			}
+		}
+	}
+
+	if earned > 0 {
+		if err := earnPoints(tx, lc.customerID, transactionID, earned, lc.program.ExpiryDays, reference, at); err != nil {
+			return err
+		}
+	}
+	lc.summary.PointsEarned = earned
+
+	balance, err := pointsBalance(tx, lc.customerID)
+	if err != nil {
+		return err
+	}
+	lc.summary.Balance = balance
+	return nil
+}
+
+// earnPoints adds a lot of points. Points owed from an earlier reversal
+// are settled first, so only the rest of the lot can be spent.
+func earnPoints(tx *sql.Tx, customerID, transactionID, points, expiryDays int, reference string, at time.Time) error {
+	var lots, balance int
+	err := tx.QueryRow(`
+		SELECT
+			IFNULL(SUM(CASE WHEN type = ? THEN remaining ELSE 0 END), 0),
+			IFNULL(SUM(points), 0)
+		FROM loyalty_ledger
+		WHERE customer_id = ?
+	`, models.PointsEarn, customerID).Scan(&lots, &balance)
+	if err != nil {
+		return err
+	}
+
+	remaining := points
+	if owed := lots - balance; owed > 0 {
+		remaining = max(0, points-owed)
+	}
+
+	expiresAt := at.AddDate(0, 0, expiryDays)
+	_, err = tx.Exec(`
+		INSERT INTO loyalty_ledger
+		(customer_id, type, points, remaining, expires_at, transaction_id, reference, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, customerID, models.PointsEarn, points, remaining, clock.ToDB(expiresAt), transactionID, reference, clock.ToDB(at))
+	return err
+}
+
+// redeemPoints spends points from the lots expiring first
+func redeemPoints(tx *sql.Tx, customerID, transactionID, points int, reference string, at time.Time) error {
+	res, err := tx.Exec(`
+		INSERT INTO loyalty_ledger (customer_id, type, points, transaction_id, reference, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, customerID, models.PointsRedeem, -points, transactionID, reference, clock.ToDB(at))
+	if err != nil {
+		return err
+	}
+	redeemID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	lots, err := openLots(tx, customerID)
+	if err != nil {
+		return err
+	}
+
+	left := points
+	for _, lot := range lots {
+		if left == 0 {
+			break
+		}
+		take := min(left, lot.remaining)
+
+		if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = remaining - ? WHERE id = ?", take, lot.id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(
+			"INSERT INTO loyalty_redemptions (redeem_id, lot_id, points) VALUES (?, ?, ?)",
+			redeemID, lot.id, take,
+		)
+		if err != nil {
+			return err
+		}
+		left -= take
+	}
+
+	if left > 0 {
+		return apperror.New(apperror.CodeInsufficientPoints, "loyalty.not_enough_points")
+	}
+	return nil
+}
+
+// reverseLoyalty undoes the points of a refunded transaction: redeemed
+// points go back to the lots they came from, earned points are taken
+// back. Earned points already spent leave the balance negative until the
+// customer earns them again.
+func reverseLoyalty(tx *sql.Tx, transactionID, refundID int, at time.Time) error {
+	rows, err := tx.Query(`
+		SELECT id, customer_id, type, points
+		FROM loyalty_ledger
+		WHERE transaction_id = ? AND type IN (?, ?)
+	`, transactionID, models.PointsEarn, models.PointsRedeem)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		id, customerID, points int
+		kind                   string
+	}
+	entries := []entry{}
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.customerID, &e.kind, &e.points); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("refund:%d", refundID)
+	for _, e := range entries {
+		switch e.kind {
+		case models.PointsRedeem:
+			_, err := tx.Exec(`
+				UPDATE loyalty_ledger
+				SET remaining = remaining + (
+					SELECT lr.points FROM loyalty_redemptions lr
+					WHERE lr.redeem_id = ? AND lr.lot_id = loyalty_ledger.id
+				)
+				WHERE id IN (SELECT lot_id FROM loyalty_redemptions WHERE redeem_id = ?)
+			`, e.id, e.id)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
+				INSERT INTO loyalty_ledger (customer_id, type, points, transaction_id, reference, created_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, e.customerID, models.PointsReverseRedeem, -e.points, transactionID, reference, clock.ToDB(at))
+			if err != nil {
+				return err
+			}
+
+		case models.PointsEarn:
+			// take the points from their own lot first, then from the
+			// lots expiring first
+			lots, err := openLots(tx, e.customerID)
+			if err != nil {
+				return err
+			}
+			sort.SliceStable(lots, func(i, j int) bool {
+				return lots[i].id == e.id && lots[j].id != e.id
+			})
+
+			left := e.points
+			for _, lot := range lots {
+				if left == 0 {
+					break
+				}
+				take := min(left, lot.remaining)
+				if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = remaining - ? WHERE id = ?", take, lot.id); err != nil {
+					return err
+				}
+				left -= take
+			}
+
+			_, err = tx.Exec(`
+				INSERT INTO loyalty_ledger (customer_id, type, points, transaction_id, reference, created_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, e.customerID, models.PointsReverseEarn, -e.points, transactionID, reference, clock.ToDB(at))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expirePoints writes off the lots past their expiry date, for one
+// customer or for everyone when customerID is 0
+func expirePoints(tx *sql.Tx, customerID int, now time.Time) (int, int, error) {
+	query := `
+		SELECT id, customer_id, remaining
+		FROM loyalty_ledger
+		WHERE type = ? AND remaining > 0 AND expires_at <= ?`
+	args := []interface{}{models.PointsEarn, clock.ToDB(now)}
+	if customerID != 0 {
+		query += " AND customer_id = ?"
+		args = append(args, customerID)
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type lot struct{ id, customerID, remaining int }
+	lots := []lot{}
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.customerID, &l.remaining); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	points := 0
+	for _, l := range lots {
+		if _, err := tx.Exec("UPDATE loyalty_ledger SET remaining = 0 WHERE id = ?", l.id); err != nil {
+			return 0, 0, err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO loyalty_ledger (customer_id, type, points, reference, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, l.customerID, models.PointsExpire, -l.remaining, fmt.Sprintf("lot:%d", l.id), clock.ToDB(now))
+		if err != nil {
+			return 0, 0, err
+		}
+		points += l.remaining
+	}
+
+	return len(lots), points, nil
+}
+
+type pointsLot struct{ id, remaining int }
+
+// openLots returns the lots of a customer with points left, expiring first
+func openLots(tx *sql.Tx, customerID int) ([]pointsLot, error) {
+	rows, err := tx.Query(`
+		SELECT id, remaining
+		FROM loyalty_ledger
+		WHERE customer_id = ? AND type = ? AND remaining > 0
+		ORDER BY expires_at, id
+	`, customerID, models.PointsEarn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := []pointsLot{}
+	for rows.Next() {
+		var l pointsLot
+		if err := rows.Scan(&l.id, &l.remaining); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
+
+// pointsBalance is the sum of every movement on the ledger
+func pointsBalance(q queryer, customerID int) (int, error) {
+	var balance int
+	err := q.QueryRow(
+		"SELECT IFNULL(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = ?",
+		customerID,
+	).Scan(&balance)
+	return balance, err
+}
+
+// lifetimePoints are the points ever earned, less those taken back by
+// refunds; spending or losing points does not lower the tier
+func lifetimePoints(q queryer, customerID int) (int, error) {
+	var points int
+	err := q.QueryRow(
+		"SELECT IFNULL(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = ? AND type IN (?, ?)",
+		customerID, models.PointsEarn, models.PointsReverseEarn,
+	).Scan(&points)
+	return points, err
+}
+
+// tierFor returns the tier reached with the lifetime points and the one
+// after it, if any. Tiers are sorted by MinPoints.
+func tierFor(tiers []models.LoyaltyTier, lifetime int) (*models.LoyaltyTier, *models.LoyaltyTier) {
+	var current *models.LoyaltyTier
+	for i := range tiers {
+		if lifetime < tiers[i].MinPoints {
+			return current, &tiers[i]
+		}
+		current = &tiers[i]
+	}
+	return current, nil
+}
+
+// earnRate returns the Rupiah per point of a category, falling back to
+// the rule of category 0
+func earnRate(program *models.LoyaltyProgram, categoryID int) int {
+	fallback := 0
+	for _, rule := range program.Rules {
+		if rule.CategoryID == categoryID {
+			return rule.RupiahPerPoint
+		}
+		if rule.CategoryID == 0 {
+			fallback = rule.RupiahPerPoint
+		}
+	}
+	return fallback
+}
+
+func customerExists(q queryer, customerID int) error {
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM customers WHERE id = ?", customerID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return apperror.NotFound("customer.not_found")
+	}
+	return nil
+}
+
+func loadLoyaltyProgram(q queryer) (*models.LoyaltyProgram, error) {
+	program := &models.LoyaltyProgram{Rules: []models.LoyaltyRule{}, Tiers: []models.LoyaltyTier{}}
+
+	err := q.QueryRow("SELECT point_value, expiry_days FROM loyalty_settings WHERE id = 1").
+		Scan(&program.PointValue, &program.ExpiryDays)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query("SELECT category_id, rupiah_per_point FROM loyalty_rules ORDER BY category_id")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var rule models.LoyaltyRule
+		if err := rows.Scan(&rule.CategoryID, &rule.RupiahPerPoint); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		program.Rules = append(program.Rules, rule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query("SELECT name, min_points, discount_percent FROM loyalty_tiers ORDER BY min_points")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var tier models.LoyaltyTier
+		if err := rows.Scan(&tier.Name, &tier.MinPoints, &tier.DiscountPercent); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		program.Tiers = append(program.Tiers, tier)
+	}
+	rows.Close()
+
+	return program, rows.Err()
+}
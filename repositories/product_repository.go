@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
 	"task-crud-kategori/models"
+	"time"
 )
 
 type ProductRepository struct {
@@ -18,7 +20,7 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 // GET ALL PRODUCTS
 // =======================
 func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
-	query := "SELECT id, name, price, stock FROM products"
+	query := "SELECT id, name, price, cost, stock FROM products"
 	args := []interface{}{}
 
 	if name != "" {
@@ -36,7 +38,7 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 
 	for rows.Next() {
 		var p models.Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock)
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Cost, &p.Stock)
 		if err != nil {
 			return nil, err
 		}
@@ -46,18 +48,70 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 	return products, nil
 }
 
+// =======================
+// CATEGORY EXISTS
+// =======================
+// CategoryExists reports whether products can be put in a category
+func (repo *ProductRepository) CategoryExists(categoryID int) (bool, error) {
+	var exists int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM categories WHERE id = ?", categoryID).Scan(&exists)
+	return exists > 0, err
+}
+
+// =======================
+// GET PRODUCTS BY CATEGORY
+// =======================
+func (repo *ProductRepository) GetByCategory(categoryID int) ([]models.Product, error) {
+	exists, err := repo.CategoryExists(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apperror.NotFound("category.not_found")
+	}
+
+	rows, err := repo.db.Query(
+		"SELECT id, name, price, cost, stock, category_id FROM products WHERE category_id = ? ORDER BY name, id",
+		categoryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Cost, &p.Stock, &p.CategoryID); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
 // =======================
 // CREATE PRODUCT
 // =======================
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES (?, ?, ?, ?)"
+func (repo *ProductRepository) Create(product *models.Product, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	result, err := repo.db.Exec(
+	now := time.Now().UTC().Truncate(time.Second)
+	query := "INSERT INTO products (name, price, cost, stock, category_id, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := tx.Exec(
 		query,
 		product.Name,
 		product.Price,
+		product.Cost,
 		product.Stock,
 		product.CategoryID,
+		clock.ToDB(now),
 	)
 	if err != nil {
 		return err
@@ -68,8 +122,17 @@ func (repo *ProductRepository) Create(product *models.Product) error {
 		return err
 	}
 
+	// opening stock
+	if err := recordStockMovement(tx, int(id), models.MovementInitial, product.Stock, "", now); err != nil {
+		return err
+	}
+
 	product.ID = int(id)
-	return nil
+	if err := audit(tx, product.ID, auditedProduct(product)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // =======================
@@ -78,7 +141,7 @@ func (repo *ProductRepository) Create(product *models.Product) error {
 func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	query := `
 	SELECT 
-		p.id, p.name, p.price, p.stock, p.category_id,
+		p.id, p.name, p.price, p.cost, p.stock, p.category_id,
 		c.id, c.name, c.description
 		FROM products p
 		JOIN categories c ON p.category_id = c.id
@@ -92,6 +155,7 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 		&product.ID,
 		&product.Name,
 		&product.Price,
+		&product.Cost,
 		&product.Stock,
 		&product.CategoryID,
 		&category.ID,
@@ -100,7 +164,7 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("produk tidak ditemukan")
+		return nil, apperror.NotFound("product.not_found")
 	}
 	if err != nil {
 		return nil, err
@@ -113,17 +177,36 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 // =======================
 // UPDATE PRODUCT
 // =======================
-func (repo *ProductRepository) Update(product *models.Product) error {
+func (repo *ProductRepository) Update(product *models.Product, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldStock, oldCost, oldPrice int
+	err = tx.QueryRow(
+		"SELECT stock, cost, price FROM products WHERE id = ?",
+		product.ID,
+	).Scan(&oldStock, &oldCost, &oldPrice)
+	if err == sql.ErrNoRows {
+		return apperror.NotFound("product.not_found")
+	}
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE products
-		SET name = ?, price = ?, stock = ?, category_id = ?
+		SET name = ?, price = ?, cost = ?, stock = ?, category_id = ?
 		WHERE id = ?
 	`
 
-	result, err := repo.db.Exec(
+	_, err = tx.Exec(
 		query,
 		product.Name,
 		product.Price,
+		product.Cost,
 		product.Stock,
 		product.CategoryID,
 		product.ID,
@@ -131,23 +214,39 @@ func (repo *ProductRepository) Update(product *models.Product) error {
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+
+	// stock corrections and price changes go to the movement ledger
+	now := time.Now().UTC().Truncate(time.Second)
+	switch {
+	case product.Stock != oldStock:
+		err = recordStockMovement(tx, product.ID, models.MovementAdjustment, product.Stock-oldStock, "", now)
+	case product.Cost != oldCost || product.Price != oldPrice:
+		err = recordStockMovement(tx, product.ID, models.MovementPrice, 0, "", now)
+	}
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+
+	if err := audit(tx, product.ID, auditedProduct(product)); err != nil {
+		return err
 	}
-	return nil
+
+	return tx.Commit()
 }
 
 // =======================
 // DELETE PRODUCT
 // =======================
-func (repo *ProductRepository) Delete(id int) error {
+func (repo *ProductRepository) Delete(id int, audit AuditFunc) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "DELETE FROM products WHERE id = ?"
 
-	result, err := repo.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -158,8 +257,20 @@ func (repo *ProductRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+		return apperror.NotFound("product.not_found")
+	}
+
+	if err := audit(tx, id, nil); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// auditedProduct is the product as the audit log keeps it, without its
+// category
+func auditedProduct(product *models.Product) *models.Product {
+	audited := *product
+	audited.Category = nil
+	return &audited
 }
@@ -2,57 +2,72 @@ package repositories
 
 import (
 	"database/sql"
+	"task-crud-kategori/clock"
 	"task-crud-kategori/models"
+	"time"
 )
 
 type ReportRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	calendar *clock.Calendar
 }
 
-func NewReportRepository(db *sql.DB) *ReportRepository {
-	return &ReportRepository{db: db}
+func NewReportRepository(db *sql.DB, calendar *clock.Calendar) *ReportRepository {
+	return &ReportRepository{db: db, calendar: calendar}
 }
 
-func (r *ReportRepository) GetSummary(startDate, endDate string) (*models.ReportSummary, error) {
+// GetSummary aggregates the business days from startDate to endDate
+// inclusive. Figures are net: a refund is taken off the day it was paid out.
+func (r *ReportRepository) GetSummary(startDate, endDate time.Time) (*models.ReportSummary, error) {
+	if ok, err := rollupsCurrent(r.db, r.calendar); err != nil || ok {
+		if err != nil {
+			return nil, err
+		}
+		return r.getSummaryFromRollups(startDate, endDate)
+	}
+
 	summary := &models.ReportSummary{}
 
-	// filter tanggal (optional)
-	dateFilter := ""
-	args := []interface{}{}
+	dateFilter, args := r.dateFilter(startDate, endDate)
+	refundFilter, refundArgs := r.refundFilter(startDate, endDate)
+	netArgs := append(append([]interface{}{}, args...), refundArgs...)
 
-	if startDate != "" && endDate != "" {
-		dateFilter = "WHERE DATE(created_at) BETWEEN ? AND ?"
-		args = append(args, startDate, endDate)
-	} else {
-		dateFilter = "WHERE DATE(created_at) = DATE('now')"
-	}
-
-	// total revenue & transaksi
+	// total revenue & transaksi, net of the refunds paid out in the period
 	err := r.db.QueryRow(`
-		SELECT 
-			IFNULL(SUM(total_amount), 0),
-			COUNT(*)
-		FROM transactions
-		`+dateFilter,
-		args...,
+		SELECT
+			IFNULL((SELECT SUM(t.total_amount) FROM transactions t `+dateFilter+`), 0)
+				- IFNULL((SELECT SUM(r.amount) FROM refunds r `+refundFilter+`), 0),
+			(SELECT COUNT(*) FROM transactions t `+dateFilter+`)
+				- (SELECT COUNT(*) FROM refunds r `+refundFilter+`)
+		`,
+		append(netArgs, netArgs...)...,
 	).Scan(&summary.TotalRevenue, &summary.TotalTransaksi)
 	if err != nil {
 		return nil, err
 	}
 
-	// produk terlaris
+	// produk terlaris, refunded items taken back
 	err = r.db.QueryRow(`
 		SELECT 
 			p.name,
-			SUM(td.quantity) AS total_qty
-		FROM transaction_details td
-		JOIN transactions t ON t.id = td.transaction_id
-		JOIN products p ON p.id = td.product_id
-		`+dateFilter+`
-		GROUP BY td.product_id
-		ORDER BY total_qty DESC
+			SUM(s.quantity) AS total_qty
+		FROM (
+			SELECT td.product_id, td.quantity
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			`+dateFilter+`
+			UNION ALL
+			SELECT td.product_id, -td.quantity
+			FROM refunds r
+			JOIN transaction_details td ON td.transaction_id = r.transaction_id
+			`+refundFilter+`
+		) s
+		JOIN products p ON p.id = s.product_id
+		GROUP BY s.product_id
+		HAVING total_qty > 0
+		ORDER BY total_qty DESC, s.product_id
 		LIMIT 1
-	`, args...).Scan(
+	`, netArgs...).Scan(
 		&summary.ProdukTerlaris.Nama,
 		&summary.ProdukTerlaris.QtyTerjual,
 	)
@@ -69,3 +84,633 @@ func (r *ReportRepository) GetSummary(startDate, endDate string) (*models.Report
 
 	return summary, nil
 }
+
+// =======================
+// GET PRODUCT SALES
+// =======================
+// GetProductSales returns every product with the quantity and revenue sold
+// in the period, including products that did not sell at all. Like the
+// summary totals, refunds are taken off the day they were paid out.
+func (r *ReportRepository) GetProductSales(startDate, endDate time.Time) ([]models.ProductSales, error) {
+	dateFilter, args := r.dateFilter(startDate, endDate)
+	refundFilter, refundArgs := r.refundFilter(startDate, endDate)
+	args = append(args, refundArgs...)
+	sales := `
+			SELECT product_id, SUM(quantity) AS qty, SUM(revenue) AS revenue
+			FROM (
+				SELECT td.product_id, td.quantity, td.subtotal AS revenue
+				FROM transaction_details td
+				JOIN transactions t ON t.id = td.transaction_id
+				` + dateFilter + `
+				UNION ALL
+				SELECT td.product_id, -td.quantity, -td.subtotal
+				FROM refunds r
+				JOIN transaction_details td ON td.transaction_id = r.transaction_id
+				` + refundFilter + `
+			)
+			GROUP BY product_id`
+
+	if ok, err := rollupsCurrent(r.db, r.calendar); err != nil {
+		return nil, err
+	} else if ok {
+		sales = `
+			SELECT
+				product_id,
+				SUM(quantity - refunded_quantity) AS qty,
+				SUM(revenue - refunds) AS revenue
+			FROM sales_daily_product
+			WHERE business_date BETWEEN ? AND ?
+			GROUP BY product_id`
+		args = rollupDateArgs(startDate, endDate)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT
+			p.id,
+			p.name,
+			IFNULL(p.category_id, 0),
+			IFNULL(c.name, ''),
+			IFNULL(s.qty, 0),
+			IFNULL(s.revenue, 0)
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN (`+sales+`
+		) s ON s.product_id = p.id
+		ORDER BY p.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.ProductSales{}
+	for rows.Next() {
+		var p models.ProductSales
+		err := rows.Scan(
+			&p.ProductID,
+			&p.Name,
+			&p.CategoryID,
+			&p.CategoryName,
+			&p.Quantity,
+			&p.Revenue,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+// dateFilter builds the WHERE clause on transactions (aliased t) covering
+// the business days from startDate to endDate inclusive
+func (r *ReportRepository) dateFilter(startDate, endDate time.Time) (string, []interface{}) {
+	from, to := r.calendar.Bounds(startDate, endDate)
+	return "WHERE t.created_at >= ? AND t.created_at < ?", []interface{}{clock.ToDB(from), clock.ToDB(to)}
+}
+
+// refundFilter is dateFilter for refunds (aliased r), by the time they
+// were paid out
+func (r *ReportRepository) refundFilter(startDate, endDate time.Time) (string, []interface{}) {
+	from, to := r.calendar.Bounds(startDate, endDate)
+	return "WHERE r.created_at >= ? AND r.created_at < ?", []interface{}{clock.ToDB(from), clock.ToDB(to)}
+}
+
+// =======================
+// GET DAILY SALES
+// =======================
+// GetDailySales returns the net sales per business day; refunds count
+// against the day they were paid out. Without rollups days are computed in
+// Go because SQLite has no notion of the store timezone.
+func (r *ReportRepository) GetDailySales(startDate, endDate time.Time) ([]models.DailySales, error) {
+	if ok, err := rollupsCurrent(r.db, r.calendar); err != nil || ok {
+		if err != nil {
+			return nil, err
+		}
+		return dailySalesFromRollups(r.db, startDate, endDate)
+	}
+	return dailySalesFromTransactions(r.db, r.calendar, startDate, endDate)
+}
+
+func dailySalesFromTransactions(q queryer, calendar *clock.Calendar, startDate, endDate time.Time) ([]models.DailySales, error) {
+	from, to := calendar.Bounds(startDate, endDate)
+	args := []interface{}{clock.ToDB(from), clock.ToDB(to)}
+
+	// sales count +1, refunds -1
+	rows, err := q.Query(`
+		SELECT
+			t.created_at,
+			t.total_amount,
+			1,
+			IFNULL((
+				SELECT SUM(td.quantity)
+				FROM transaction_details td
+				WHERE td.transaction_id = t.id
+			), 0)
+		FROM transactions t
+		WHERE t.created_at >= ? AND t.created_at < ?
+		UNION ALL
+		SELECT
+			r.created_at,
+			-r.amount,
+			-1,
+			-IFNULL((
+				SELECT SUM(td.quantity)
+				FROM transaction_details td
+				WHERE td.transaction_id = r.transaction_id
+			), 0)
+		FROM refunds r
+		WHERE r.created_at >= ? AND r.created_at < ?
+		ORDER BY 1
+	`, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []models.DailySales{}
+	for rows.Next() {
+		var createdAt time.Time
+		var amount, count, items int
+		if err := rows.Scan(&createdAt, &amount, &count, &items); err != nil {
+			return nil, err
+		}
+
+		date := calendar.DateOf(createdAt).Format(clock.DateLayout)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, models.DailySales{Date: date})
+		}
+		day := &days[len(days)-1]
+		day.Revenue += amount
+		day.Transactions += count
+		day.ItemsSold += items
+	}
+
+	return days, rows.Err()
+}
+
+// =======================
+// GET HOURLY SALES
+// =======================
+// GetHourlySales returns the 7x24 weekday/hour grid of sales, Monday 00:00
+// first. The weekday is that of the business day, so with a cut-off hour
+// sales after midnight count towards the previous evening. Like the summary
+// the grid is net: a refund is taken off the hour it was paid out.
+func (r *ReportRepository) GetHourlySales(startDate, endDate time.Time) ([]models.HeatmapCell, error) {
+	dateFilter, args := r.dateFilter(startDate, endDate)
+	refundFilter, refundArgs := r.refundFilter(startDate, endDate)
+
+	// sales count +1, refunds -1
+	rows, err := r.db.Query(`
+		SELECT t.created_at, t.total_amount, 1
+		FROM transactions t
+		`+dateFilter+`
+		UNION ALL
+		SELECT r.created_at, -r.amount, -1
+		FROM refunds r
+		`+refundFilter, append(args, refundArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cells := make([]models.HeatmapCell, 7*24)
+	for i := range cells {
+		weekday := i/24 + 1
+		cells[i] = models.HeatmapCell{
+			Weekday: weekday,
+			DayName: time.Weekday(weekday % 7).String(),
+			Hour:    i % 24,
+		}
+	}
+
+	for rows.Next() {
+		var createdAt time.Time
+		var amount, count int
+		if err := rows.Scan(&createdAt, &amount, &count); err != nil {
+			return nil, err
+		}
+
+		weekday := (int(r.calendar.DateOf(createdAt).Weekday()) + 6) % 7 // Monday = 0
+		cell := &cells[weekday*24+createdAt.In(r.calendar.Location).Hour()]
+		cell.Revenue += amount
+		cell.Transactions += count
+	}
+
+	return cells, rows.Err()
+}
+
+// =======================
+// READ FROM ROLLUPS
+// =======================
+// Reports covering whole business days read the daily rollups instead of
+// scanning transactions, as long as the rollups are current.
+
+func (r *ReportRepository) getSummaryFromRollups(startDate, endDate time.Time) (*models.ReportSummary, error) {
+	summary := &models.ReportSummary{}
+	args := rollupDateArgs(startDate, endDate)
+
+	err := r.db.QueryRow(`
+		SELECT IFNULL(SUM(revenue - refunds), 0), IFNULL(SUM(transactions - refund_count), 0)
+		FROM sales_daily
+		WHERE business_date BETWEEN ? AND ?
+	`, args...).Scan(&summary.TotalRevenue, &summary.TotalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRow(`
+		SELECT p.name, SUM(s.quantity - s.refunded_quantity) AS total_qty
+		FROM sales_daily_product s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.business_date BETWEEN ? AND ?
+		GROUP BY s.product_id
+		HAVING total_qty > 0
+		ORDER BY total_qty DESC, s.product_id
+		LIMIT 1
+	`, args...).Scan(
+		&summary.ProdukTerlaris.Nama,
+		&summary.ProdukTerlaris.QtyTerjual,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func dailySalesFromRollups(q queryer, startDate, endDate time.Time) ([]models.DailySales, error) {
+	rows, err := q.Query(`
+		SELECT
+			d.business_date,
+			d.revenue - d.refunds,
+			d.transactions - d.refund_count,
+			d.items_sold - IFNULL((
+				SELECT SUM(p.refunded_quantity)
+				FROM sales_daily_product p
+				WHERE p.business_date = d.business_date
+			), 0)
+		FROM sales_daily d
+		WHERE d.business_date BETWEEN ? AND ? AND (d.transactions > 0 OR d.refund_count > 0)
+		ORDER BY d.business_date
+	`, rollupDateArgs(startDate, endDate)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []models.DailySales{}
+	for rows.Next() {
+		var d models.DailySales
+		if err := rows.Scan(&d.Date, &d.Revenue, &d.Transactions, &d.ItemsSold); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+func rollupDateArgs(startDate, endDate time.Time) []interface{} {
+	return []interface{}{startDate.Format(clock.DateLayout), endDate.Format(clock.DateLayout)}
+}
+
+// =======================
+// GET STOCK AGING
+// =======================
+// GetStockAging returns every product with stock on hand, the quantity
+// sold since the start of business day since, and its last sale
+func (r *ReportRepository) GetStockAging(since time.Time) ([]models.StockAgingItem, error) {
+	from, _ := r.calendar.Bounds(since, since)
+
+	rows, err := r.db.Query(`
+		SELECT
+			p.id,
+			p.name,
+			IFNULL(p.category_id, 0),
+			IFNULL(c.name, ''),
+			p.stock,
+			p.price,
+			p.cost,
+			IFNULL((
+				SELECT SUM(td.quantity)
+				FROM transaction_details td
+				JOIN transactions t ON t.id = td.transaction_id
+				WHERE td.product_id = p.id AND t.created_at >= ?
+			), 0),
+			(
+				SELECT MAX(t.created_at)
+				FROM transaction_details td
+				JOIN transactions t ON t.id = td.transaction_id
+				WHERE td.product_id = p.id
+			)
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.stock > 0
+		ORDER BY p.id
+	`, clock.ToDB(from))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.StockAgingItem{}
+	for rows.Next() {
+		var item models.StockAgingItem
+		var lastSold sql.NullString
+		err := rows.Scan(
+			&item.ProductID,
+			&item.Name,
+			&item.CategoryID,
+			&item.CategoryName,
+			&item.Stock,
+			&item.Price,
+			&item.Cost,
+			&item.QuantitySold,
+			&lastSold,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// aggregates come back as text, not as DATETIME
+		if lastSold.Valid {
+			t, err := time.ParseInLocation(clock.DBLayout, lastSold.String, time.UTC)
+			if err != nil {
+				return nil, err
+			}
+			t = t.In(r.calendar.Location)
+			item.LastSoldAt = &t
+		}
+		item.StockValue = item.Stock * item.Price
+		item.CostValue = item.Stock * item.Cost
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// =======================
+// GET INVENTORY VALUATION
+// =======================
+// GetInventoryValuation reconstructs the stock at the end of business day
+// asOf by undoing every later movement. Cost and price are the last ones
+// recorded on or before that moment, falling back to the current values.
+// Products created later are left out; deleted products cannot be shown.
+// Products from before created_at was recorded are always included.
+func (r *ReportRepository) GetInventoryValuation(asOf time.Time) (time.Time, []models.ValuationItem, error) {
+	_, end := r.calendar.Bounds(asOf, asOf)
+	at := clock.ToDB(end)
+
+	rows, err := r.db.Query(`
+		SELECT
+			p.id,
+			p.name,
+			IFNULL(p.category_id, 0),
+			IFNULL(c.name, ''),
+			p.stock - IFNULL((
+				SELECT SUM(m.quantity)
+				FROM stock_movements m
+				WHERE m.product_id = p.id AND m.created_at >= ?
+			), 0),
+			IFNULL((
+				SELECT m.unit_cost
+				FROM stock_movements m
+				WHERE m.product_id = p.id AND m.created_at < ? AND m.unit_cost IS NOT NULL
+				ORDER BY m.created_at DESC, m.id DESC
+				LIMIT 1
+			), p.cost),
+			IFNULL((
+				SELECT m.unit_price
+				FROM stock_movements m
+				WHERE m.product_id = p.id AND m.created_at < ? AND m.unit_price IS NOT NULL
+				ORDER BY m.created_at DESC, m.id DESC
+				LIMIT 1
+			), p.price)
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.created_at IS NULL OR p.created_at < ?
+		ORDER BY p.id
+	`, at, at, at, at)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ValuationItem{}
+	for rows.Next() {
+		var item models.ValuationItem
+		err := rows.Scan(
+			&item.ProductID,
+			&item.Name,
+			&item.CategoryID,
+			&item.CategoryName,
+			&item.Quantity,
+			&item.UnitCost,
+			&item.UnitPrice,
+		)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		item.CostValue = item.Quantity * item.UnitCost
+		item.RetailValue = item.Quantity * item.UnitPrice
+		items = append(items, item)
+	}
+
+	return end, items, rows.Err()
+}
+
+// =======================
+// GET BASKETS
+// =======================
+// GetBaskets returns the product ids of every transaction in the period,
+// leaving out refunded transactions, plus the names of those products
+func (r *ReportRepository) GetBaskets(startDate, endDate time.Time) ([][]int, map[int]string, error) {
+	dateFilter, args := r.dateFilter(startDate, endDate)
+
+	rows, err := r.db.Query(`
+		SELECT td.transaction_id, td.product_id, IFNULL(p.name, '')
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		LEFT JOIN products p ON p.id = td.product_id
+		`+dateFilter+`
+		AND NOT EXISTS (SELECT 1 FROM refunds rf WHERE rf.transaction_id = t.id)
+		ORDER BY td.transaction_id
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	baskets := [][]int{}
+	names := map[int]string{}
+	lastID := 0
+	for rows.Next() {
+		var transactionID, productID int
+		var name string
+		if err := rows.Scan(&transactionID, &productID, &name); err != nil {
+			return nil, nil, err
+		}
+
+		if transactionID != lastID {
+			baskets = append(baskets, []int{})
+			lastID = transactionID
+		}
+		baskets[len(baskets)-1] = append(baskets[len(baskets)-1], productID)
+		names[productID] = name
+	}
+
+	return baskets, names, rows.Err()
+}
+
+// =======================
+// GET DEMAND HISTORY
+// =======================
+// GetDemandHistory returns the units sold per business day of every
+// product, or of one product when productID is not 0. Refunded
+// transactions are not demand and are left out.
+func (r *ReportRepository) GetDemandHistory(startDate, endDate time.Time, productID int) ([]models.DemandHistory, error) {
+	productFilter := ""
+	productArgs := []interface{}{}
+	if productID != 0 {
+		productFilter = "WHERE id = ?"
+		productArgs = append(productArgs, productID)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, name, stock, created_at
+		FROM products
+		`+productFilter+`
+		ORDER BY id
+	`, productArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.DemandHistory{}
+	index := map[int]int{}
+	for rows.Next() {
+		var h models.DemandHistory
+		var createdAt sql.NullTime
+		if err := rows.Scan(&h.ProductID, &h.Name, &h.Stock, &createdAt); err != nil {
+			return nil, err
+		}
+		if createdAt.Valid {
+			h.CreatedAt = &createdAt.Time
+		}
+		h.Daily = map[string]int{}
+		index[h.ProductID] = len(history)
+		history = append(history, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	dateFilter, args := r.dateFilter(startDate, endDate)
+	if productID != 0 {
+		dateFilter += " AND td.product_id = ?"
+		args = append(args, productID)
+	}
+
+	sales, err := r.db.Query(`
+		SELECT td.product_id, t.created_at, td.quantity
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		`+dateFilter+`
+		AND NOT EXISTS (SELECT 1 FROM refunds rf WHERE rf.transaction_id = t.id)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer sales.Close()
+
+	for sales.Next() {
+		var id, quantity int
+		var createdAt time.Time
+		if err := sales.Scan(&id, &createdAt, &quantity); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[id]
+		if !ok {
+			continue
+		}
+		date := r.calendar.DateOf(createdAt).Format(clock.DateLayout)
+		history[i].Daily[date] += quantity
+	}
+
+	return history, sales.Err()
+}
+
+// =======================
+// GET CASHIER SALES
+// =======================
+// GetCashierSales returns the sales and voids of every cashier per shift
+// in the period. Sales count where they were rung up, voids (refunds)
+// where they were processed, so a void can land in a later shift than
+// its sale. Sales from before users existed have no cashier.
+func (r *ReportRepository) GetCashierSales(startDate, endDate time.Time) ([]models.CashierShiftSales, error) {
+	from, to := r.calendar.Bounds(startDate, endDate)
+	rows, err := r.db.Query(`
+		WITH sales AS (
+			SELECT shift_id, user_id, COUNT(*) AS n, SUM(total_amount) AS amount
+			FROM transactions
+			WHERE created_at >= ? AND created_at < ?
+			GROUP BY shift_id, user_id
+		), voids AS (
+			SELECT shift_id, user_id, COUNT(*) AS n, SUM(amount) AS amount
+			FROM refunds
+			WHERE created_at >= ? AND created_at < ?
+			GROUP BY shift_id, user_id
+		), cashier_shifts AS (
+			SELECT shift_id, user_id FROM sales
+			UNION
+			SELECT shift_id, user_id FROM voids
+		)
+		SELECT
+			k.shift_id,
+			sh.opened_at,
+			k.user_id,
+			e.id,
+			COALESCE(e.name, NULLIF(u.name, ''), u.username, ''),
+			IFNULL(s.n, 0),
+			IFNULL(s.amount, 0),
+			IFNULL(v.n, 0),
+			IFNULL(v.amount, 0)
+		FROM cashier_shifts k
+		LEFT JOIN sales s ON s.shift_id IS k.shift_id AND s.user_id IS k.user_id
+		LEFT JOIN voids v ON v.shift_id IS k.shift_id AND v.user_id IS k.user_id
+		LEFT JOIN shifts sh ON sh.id = k.shift_id
+		LEFT JOIN users u ON u.id = k.user_id
+		LEFT JOIN employees e ON e.user_id = k.user_id
+		ORDER BY k.shift_id, k.user_id
+	`, clock.ToDB(from), clock.ToDB(to), clock.ToDB(from), clock.ToDB(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.CashierShiftSales{}
+	for rows.Next() {
+		var c models.CashierShiftSales
+		var shiftID, userID, employeeID sql.NullInt64
+		var openedAt sql.NullTime
+		err := rows.Scan(&shiftID, &openedAt, &userID, &employeeID, &c.Cashier,
+			&c.Transactions, &c.Sales, &c.Voids, &c.VoidAmount)
+		if err != nil {
+			return nil, err
+		}
+		c.ShiftID = nullIntPtr(shiftID)
+		c.UserID = nullIntPtr(userID)
+		c.EmployeeID = nullIntPtr(employeeID)
+		if openedAt.Valid {
+			t := openedAt.Time.In(r.calendar.Location)
+			c.ShiftOpenedAt = &t
+		}
+		result = append(result, c)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,552 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// RollupRepository maintains the daily sales rollup tables
+type RollupRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewRollupRepository creates a new instance of RollupRepository
+func NewRollupRepository(db *sql.DB, calendar *clock.Calendar) *RollupRepository {
+	return &RollupRepository{db: db, calendar: calendar}
+}
+
+// =======================
+// ROLLUPS CURRENT
+// =======================
+// IsCurrent reports whether the rollups were built with the running
+// timezone and cut-off hour and can be used by reports
+func (repo *RollupRepository) IsCurrent() (bool, error) {
+	return rollupsCurrent(repo.db, repo.calendar)
+}
+
+func rollupsCurrent(q queryer, calendar *clock.Calendar) (bool, error) {
+	var timezone string
+	var cutoffHour int
+	err := q.QueryRow("SELECT timezone, cutoff_hour FROM rollup_state WHERE id = 1").Scan(&timezone, &cutoffHour)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return timezone == calendar.Location.String() && cutoffHour == calendar.CutoffHour, nil
+}
+
+// =======================
+// REBUILD ROLLUPS
+// =======================
+// Rebuild recomputes all rollups from transactions and refunds and marks
+// them current. It returns the number of business days written.
+func (repo *RollupRepository) Rebuild() (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	set, err := repo.compute(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, table := range []string{"sales_daily", "sales_daily_product", "sales_daily_category", "sales_daily_payment"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := applyRollupDelta(tx, set); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO rollup_state (id, timezone, cutoff_hour, built_at)
+		VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			timezone = excluded.timezone,
+			cutoff_hour = excluded.cutoff_hour,
+			built_at = excluded.built_at
+	`, repo.calendar.Location.String(), repo.calendar.CutoffHour, clock.ToDB(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(set.days), nil
+}
+
+// =======================
+// CHECK ROLLUPS
+// =======================
+// Check recomputes the rollups in memory and lists every row that differs
+// from the stored tables, and every day on which the net daily sales read
+// from the rollups differ from those read from transactions
+func (repo *RollupRepository) Check() (*models.RollupCheck, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := rollupsCurrent(tx, repo.calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := repo.compute(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := loadRollups(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &models.RollupCheck{
+		Current:    current,
+		CheckedAt:  repo.calendar.Now().Truncate(time.Second),
+		Days:       len(expected.days),
+		Mismatches: []models.RollupMismatch{},
+	}
+
+	diff := func(table, key string, want, got interface{}) {
+		w, g := fmt.Sprintf("%+v", want), fmt.Sprintf("%+v", got)
+		if w != g {
+			check.Mismatches = append(check.Mismatches, models.RollupMismatch{
+				Table: table, Key: key, Expected: w, Actual: g,
+			})
+		}
+	}
+
+	for _, date := range unionKeys(expected.days, actual.days) {
+		diff("sales_daily", date, valueOr(expected.days[date]), valueOr(actual.days[date]))
+	}
+	for _, key := range unionKeys(expected.products, actual.products) {
+		diff("sales_daily_product", key.String(), valueOr(expected.products[key]), valueOr(actual.products[key]))
+	}
+	for _, key := range unionKeys(expected.categories, actual.categories) {
+		diff("sales_daily_category", key.String(), valueOr(expected.categories[key]), valueOr(actual.categories[key]))
+	}
+	for _, key := range unionKeys(expected.payments, actual.payments) {
+		diff("sales_daily_payment", key.String(), valueOr(expected.payments[key]), valueOr(actual.payments[key]))
+	}
+
+	// the net daily sales reports must agree whichever way they are read
+	if dates := unionKeys(expected.days, actual.days); len(dates) > 0 {
+		first, err := repo.calendar.ParseDate(dates[0])
+		if err != nil {
+			return nil, err
+		}
+		last, err := repo.calendar.ParseDate(dates[len(dates)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := dailySalesFromTransactions(tx, repo.calendar, first, last)
+		if err != nil {
+			return nil, err
+		}
+		rolled, err := dailySalesFromRollups(tx, first, last)
+		if err != nil {
+			return nil, err
+		}
+
+		want := map[string]*models.DailySales{}
+		for i := range raw {
+			want[raw[i].Date] = &raw[i]
+		}
+		got := map[string]*models.DailySales{}
+		for i := range rolled {
+			got[rolled[i].Date] = &rolled[i]
+		}
+		for _, date := range unionKeys(want, got) {
+			diff("daily_sales", date, valueOr(want[date]), valueOr(got[date]))
+		}
+	}
+
+	check.Consistent = len(check.Mismatches) == 0
+	return check, nil
+}
+
+// compute builds the rollups from the raw transactions
+func (repo *RollupRepository) compute(q queryer) (*rollupSet, error) {
+	set := newRollupSet()
+	dates := map[int]string{}
+
+	// sales
+	rows, err := q.Query(`
+		SELECT id, created_at, total_amount, discount_amount, payment_method
+		FROM transactions
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, amount, discount int
+		var createdAt time.Time
+		var method string
+		if err := rows.Scan(&id, &createdAt, &amount, &discount, &method); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dates[id] = repo.calendar.DateOf(createdAt).Format(clock.DateLayout)
+		set.addSale(dates[id], amount, discount, method)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// sale lines
+	rows, err = q.Query(`
+		SELECT td.transaction_id, td.product_id, IFNULL(p.category_id, 0), td.quantity, td.subtotal
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var transactionID, productID, categoryID, quantity, subtotal int
+		if err := rows.Scan(&transactionID, &productID, &categoryID, &quantity, &subtotal); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		date, ok := dates[transactionID]
+		if !ok {
+			continue // orphan detail without transaction
+		}
+		set.addSaleLine(date, productID, categoryID, quantity, subtotal)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// refunds, on the day they were paid out
+	rows, err = q.Query("SELECT created_at, amount, payment_method FROM refunds")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var createdAt time.Time
+		var amount int
+		var method string
+		if err := rows.Scan(&createdAt, &amount, &method); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		set.addRefund(repo.calendar.DateOf(createdAt).Format(clock.DateLayout), amount, method)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// refunded lines
+	rows, err = q.Query(`
+		SELECT r.created_at, td.product_id, IFNULL(p.category_id, 0), td.quantity, td.subtotal
+		FROM refunds r
+		JOIN transaction_details td ON td.transaction_id = r.transaction_id
+		LEFT JOIN products p ON p.id = td.product_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var createdAt time.Time
+		var productID, categoryID, quantity, subtotal int
+		if err := rows.Scan(&createdAt, &productID, &categoryID, &quantity, &subtotal); err != nil {
+			return nil, err
+		}
+		set.addRefundLine(repo.calendar.DateOf(createdAt).Format(clock.DateLayout), productID, categoryID, quantity, subtotal)
+	}
+
+	return set, rows.Err()
+}
+
+// loadRollups reads the stored rollup tables
+func loadRollups(q queryer) (*rollupSet, error) {
+	set := newRollupSet()
+
+	rows, err := q.Query(`
+		SELECT business_date, transactions, revenue, gross_sales, discounts, items_sold, refund_count, refunds
+		FROM sales_daily
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var d models.DailyRollup
+		err := rows.Scan(&d.Date, &d.Transactions, &d.Revenue, &d.GrossSales, &d.Discounts, &d.ItemsSold, &d.RefundCount, &d.Refunds)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		set.days[d.Date] = &d
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for table, target := range map[string]map[itemKey]*models.ItemRollup{
+		"sales_daily_product":  set.products,
+		"sales_daily_category": set.categories,
+	} {
+		idColumn := "product_id"
+		if table == "sales_daily_category" {
+			idColumn = "category_id"
+		}
+
+		rows, err := q.Query(`
+			SELECT business_date, ` + idColumn + `, quantity, revenue, refunded_quantity, refunds
+			FROM ` + table)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var item models.ItemRollup
+			err := rows.Scan(&item.Date, &item.ID, &item.Quantity, &item.Revenue, &item.RefundedQuantity, &item.Refunds)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			target[itemKey{item.Date, item.ID}] = &item
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err = q.Query(`
+		SELECT business_date, payment_method, transactions, sales, refunds
+		FROM sales_daily_payment
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.PaymentRollup
+		if err := rows.Scan(&p.Date, &p.PaymentMethod, &p.Transactions, &p.Sales, &p.Refunds); err != nil {
+			return nil, err
+		}
+		set.payments[paymentKey{p.Date, p.PaymentMethod}] = &p
+	}
+
+	return set, rows.Err()
+}
+
+// =======================
+// ROLLUP DELTAS
+// =======================
+
+type itemKey struct {
+	date string
+	id   int
+}
+
+func (k itemKey) String() string { return fmt.Sprintf("%s/%d", k.date, k.id) }
+
+type paymentKey struct {
+	date   string
+	method string
+}
+
+func (k paymentKey) String() string { return k.date + "/" + k.method }
+
+// rollupSet holds rollup rows, either a full recomputation or the delta
+// of a single sale or refund
+type rollupSet struct {
+	days       map[string]*models.DailyRollup
+	products   map[itemKey]*models.ItemRollup
+	categories map[itemKey]*models.ItemRollup
+	payments   map[paymentKey]*models.PaymentRollup
+}
+
+func newRollupSet() *rollupSet {
+	return &rollupSet{
+		days:       map[string]*models.DailyRollup{},
+		products:   map[itemKey]*models.ItemRollup{},
+		categories: map[itemKey]*models.ItemRollup{},
+		payments:   map[paymentKey]*models.PaymentRollup{},
+	}
+}
+
+func (s *rollupSet) day(date string) *models.DailyRollup {
+	if d, ok := s.days[date]; ok {
+		return d
+	}
+	s.days[date] = &models.DailyRollup{Date: date}
+	return s.days[date]
+}
+
+func (s *rollupSet) item(items map[itemKey]*models.ItemRollup, date string, id int) *models.ItemRollup {
+	key := itemKey{date, id}
+	if item, ok := items[key]; ok {
+		return item
+	}
+	items[key] = &models.ItemRollup{Date: date, ID: id}
+	return items[key]
+}
+
+func (s *rollupSet) payment(date, method string) *models.PaymentRollup {
+	key := paymentKey{date, method}
+	if p, ok := s.payments[key]; ok {
+		return p
+	}
+	s.payments[key] = &models.PaymentRollup{Date: date, PaymentMethod: method}
+	return s.payments[key]
+}
+
+// addSale books a transaction; amount is after discount
+func (s *rollupSet) addSale(date string, amount, discount int, method string) {
+	d := s.day(date)
+	d.Transactions++
+	d.Revenue += amount
+	d.Discounts += discount
+
+	p := s.payment(date, method)
+	p.Transactions++
+	p.Sales += amount
+}
+
+func (s *rollupSet) addSaleLine(date string, productID, categoryID, quantity, subtotal int) {
+	d := s.day(date)
+	d.ItemsSold += quantity
+	d.GrossSales += subtotal
+
+	for _, item := range []*models.ItemRollup{
+		s.item(s.products, date, productID),
+		s.item(s.categories, date, categoryID),
+	} {
+		item.Quantity += quantity
+		item.Revenue += subtotal
+	}
+}
+
+func (s *rollupSet) addRefund(date string, amount int, method string) {
+	d := s.day(date)
+	d.RefundCount++
+	d.Refunds += amount
+
+	s.payment(date, method).Refunds += amount
+}
+
+func (s *rollupSet) addRefundLine(date string, productID, categoryID, quantity, subtotal int) {
+	for _, item := range []*models.ItemRollup{
+		s.item(s.products, date, productID),
+		s.item(s.categories, date, categoryID),
+	} {
+		item.RefundedQuantity += quantity
+		item.Refunds += subtotal
+	}
+}
+
+// applyRollupDelta adds every row of set to the rollup tables
+func applyRollupDelta(tx *sql.Tx, set *rollupSet) error {
+	for _, d := range set.days {
+		_, err := tx.Exec(`
+			INSERT INTO sales_daily
+			(business_date, transactions, revenue, gross_sales, discounts, items_sold, refund_count, refunds)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(business_date) DO UPDATE SET
+				transactions = transactions + excluded.transactions,
+				revenue = revenue + excluded.revenue,
+				gross_sales = gross_sales + excluded.gross_sales,
+				discounts = discounts + excluded.discounts,
+				items_sold = items_sold + excluded.items_sold,
+				refund_count = refund_count + excluded.refund_count,
+				refunds = refunds + excluded.refunds
+		`, d.Date, d.Transactions, d.Revenue, d.GrossSales, d.Discounts, d.ItemsSold, d.RefundCount, d.Refunds)
+		if err != nil {
+			return err
+		}
+	}
+
+	for table, items := range map[string]map[itemKey]*models.ItemRollup{
+		"sales_daily_product":  set.products,
+		"sales_daily_category": set.categories,
+	} {
+		idColumn := "product_id"
+		if table == "sales_daily_category" {
+			idColumn = "category_id"
+		}
+
+		for _, item := range items {
+			_, err := tx.Exec(`
+				INSERT INTO `+table+`
+				(business_date, `+idColumn+`, quantity, revenue, refunded_quantity, refunds)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(business_date, `+idColumn+`) DO UPDATE SET
+					quantity = quantity + excluded.quantity,
+					revenue = revenue + excluded.revenue,
+					refunded_quantity = refunded_quantity + excluded.refunded_quantity,
+					refunds = refunds + excluded.refunds
+			`, item.Date, item.ID, item.Quantity, item.Revenue, item.RefundedQuantity, item.Refunds)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range set.payments {
+		_, err := tx.Exec(`
+			INSERT INTO sales_daily_payment
+			(business_date, payment_method, transactions, sales, refunds)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(business_date, payment_method) DO UPDATE SET
+				transactions = transactions + excluded.transactions,
+				sales = sales + excluded.sales,
+				refunds = refunds + excluded.refunds
+		`, p.Date, p.PaymentMethod, p.Transactions, p.Sales, p.Refunds)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys[K comparable, V any](a, b map[K]V) []K {
+	seen := map[K]bool{}
+	keys := []K{}
+	for _, m := range []map[K]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// valueOr dereferences a rollup row, a missing row compares as "missing"
+func valueOr[T any](v *T) interface{} {
+	if v == nil {
+		return "missing"
+	}
+	return *v
+}
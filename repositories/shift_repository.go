@@ -0,0 +1,427 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// ShiftRepository handles register shifts and their X/Z reports
+type ShiftRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewShiftRepository creates a new instance of ShiftRepository
+func NewShiftRepository(db *sql.DB, calendar *clock.Calendar) *ShiftRepository {
+	return &ShiftRepository{db: db, calendar: calendar}
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const shiftColumns = `
+	id, status, opened_by, opening_float, opened_at,
+	closed_by, closed_at, counted_cash, z_number, note`
+
+// =======================
+// OPEN SHIFT
+// =======================
+func (repo *ShiftRepository) Open(shift *models.Shift) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := openShiftID(tx)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		return apperror.Conflict("shift.already_open")
+	}
+
+	openedAt := time.Now().UTC().Truncate(time.Second)
+	result, err := tx.Exec(`
+		INSERT INTO shifts (status, opened_by, opening_float, opened_at)
+		VALUES (?, ?, ?, ?)
+	`, models.ShiftOpen, shift.OpenedBy, shift.OpeningFloat, clock.ToDB(openedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	shift.ID = int(id)
+	shift.Status = models.ShiftOpen
+	shift.OpenedAt = openedAt.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// GET ALL SHIFTS
+// =======================
+func (repo *ShiftRepository) GetAll() ([]models.Shift, error) {
+	rows, err := repo.db.Query("SELECT " + shiftColumns + " FROM shifts ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []models.Shift{}
+	for rows.Next() {
+		var s models.Shift
+		if err := repo.scanShift(rows, &s); err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, s)
+	}
+
+	return shifts, rows.Err()
+}
+
+// =======================
+// GET SHIFT BY ID
+// =======================
+func (repo *ShiftRepository) GetByID(id int) (*models.Shift, error) {
+	return repo.getShift(repo.db, "SELECT "+shiftColumns+" FROM shifts WHERE id = ?", id)
+}
+
+// =======================
+// GET CURRENT SHIFT
+// =======================
+func (repo *ShiftRepository) GetCurrent() (*models.Shift, error) {
+	var shift models.Shift
+	err := repo.scanShift(repo.db.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE status = ?", models.ShiftOpen), &shift)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("shift.none_open")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// =======================
+// X-REPORT
+// =======================
+// XReport is a snapshot of an open shift; it does not change anything
+func (repo *ShiftRepository) XReport(id int) (*models.ShiftReport, error) {
+	shift, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status != models.ShiftOpen {
+		return nil, apperror.Conflict("shift.closed")
+	}
+
+	report, err := repo.buildReport(repo.db, shift)
+	if err != nil {
+		return nil, err
+	}
+	report.Type = "X"
+	return report, nil
+}
+
+// =======================
+// CLOSE SHIFT (Z-REPORT)
+// =======================
+// Close ends a shift with the counted cash and stores its Z-report under
+// the next sequential number. Stored Z-reports cannot be changed.
+func (repo *ShiftRepository) Close(id int, req models.CloseShiftRequest) (*models.ShiftReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shift, err := repo.getShift(tx, "SELECT "+shiftColumns+" FROM shifts WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status != models.ShiftOpen {
+		return nil, apperror.Conflict("shift.already_closed")
+	}
+
+	report, err := repo.buildReport(tx, shift)
+	if err != nil {
+		return nil, err
+	}
+
+	var zNumber int
+	if err := tx.QueryRow("SELECT IFNULL(MAX(z_number), 0) + 1 FROM z_reports").Scan(&zNumber); err != nil {
+		return nil, err
+	}
+
+	closedAt := time.Now().UTC().Truncate(time.Second)
+	localClosedAt := closedAt.In(repo.calendar.Location)
+	variance := req.CountedCash - report.ExpectedCash
+
+	report.Type = "Z"
+	report.ZNumber = &zNumber
+	report.ClosedBy = req.ClosedBy
+	report.ClosedAt = &localClosedAt
+	report.GeneratedAt = localClosedAt
+	report.CountedCash = &req.CountedCash
+	report.Variance = &variance
+	report.Note = req.Note
+
+	_, err = tx.Exec(`
+		UPDATE shifts
+		SET status = ?, closed_by = ?, closed_at = ?, counted_cash = ?, z_number = ?, note = ?
+		WHERE id = ?
+	`, models.ShiftClosed, req.ClosedBy, clock.ToDB(closedAt), req.CountedCash, zNumber, req.Note, id)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO z_reports (z_number, shift_id, report, created_at)
+		VALUES (?, ?, ?, ?)
+	`, zNumber, id, string(payload), clock.ToDB(closedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// =======================
+// GET ALL Z-REPORTS
+// =======================
+func (repo *ShiftRepository) GetZReports() ([]models.ShiftReport, error) {
+	rows, err := repo.db.Query("SELECT report FROM z_reports ORDER BY z_number DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.ShiftReport{}
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+
+		var report models.ShiftReport
+		if err := json.Unmarshal([]byte(payload), &report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+// =======================
+// GET Z-REPORT BY NUMBER
+// =======================
+func (repo *ShiftRepository) GetZReport(number int) (*models.ShiftReport, error) {
+	var payload string
+	err := repo.db.QueryRow("SELECT report FROM z_reports WHERE z_number = ?", number).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("zreport.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report models.ShiftReport
+	if err := json.Unmarshal([]byte(payload), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// buildReport sums the sales, refunds and kasbon repayments booked on a
+// shift. Credit sales bring in no money, so only cash counts towards the
+// expected cash.
+func (repo *ShiftRepository) buildReport(q queryer, shift *models.Shift) (*models.ShiftReport, error) {
+	report := &models.ShiftReport{
+		ShiftID:      shift.ID,
+		OpenedBy:     shift.OpenedBy,
+		OpenedAt:     shift.OpenedAt,
+		GeneratedAt:  time.Now().In(repo.calendar.Location).Truncate(time.Second),
+		OpeningFloat: shift.OpeningFloat,
+	}
+
+	totals := map[string]*models.PaymentMethodTotal{}
+	for _, method := range models.PaymentMethods {
+		totals[method] = &models.PaymentMethodTotal{PaymentMethod: method}
+	}
+	order := append([]string{}, models.PaymentMethods...)
+	totalFor := func(method string) *models.PaymentMethodTotal {
+		if t, ok := totals[method]; ok {
+			return t
+		}
+		totals[method] = &models.PaymentMethodTotal{PaymentMethod: method}
+		order = append(order, method)
+		return totals[method]
+	}
+
+	// sales per payment method
+	rows, err := q.Query(`
+		SELECT payment_method, COUNT(*), IFNULL(SUM(total_amount), 0), IFNULL(SUM(discount_amount), 0)
+		FROM transactions
+		WHERE shift_id = ?
+		GROUP BY payment_method
+	`, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var method string
+		var count, sales, discounts int
+		if err := rows.Scan(&method, &count, &sales, &discounts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t := totalFor(method)
+		t.Transactions += count
+		t.Sales += sales
+		report.Transactions += count
+		report.NetSales += sales
+		report.Discounts += discounts
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// items and gross sales
+	err = q.QueryRow(`
+		SELECT IFNULL(SUM(td.quantity), 0), IFNULL(SUM(td.subtotal), 0)
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE t.shift_id = ?
+	`, shift.ID).Scan(&report.ItemsSold, &report.GrossSales)
+	if err != nil {
+		return nil, err
+	}
+
+	// refunds paid out during the shift
+	rows, err = q.Query(`
+		SELECT payment_method, COUNT(*), IFNULL(SUM(amount), 0)
+		FROM refunds
+		WHERE shift_id = ?
+		GROUP BY payment_method
+	`, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var method string
+		var count, amount int
+		if err := rows.Scan(&method, &count, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		totalFor(method).Refunds += amount
+		report.RefundCount += count
+		report.Refunds += amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// kasbon repayments taken during the shift
+	rows, err = q.Query(`
+		SELECT payment_method, IFNULL(SUM(amount), 0)
+		FROM customer_repayments
+		WHERE shift_id = ?
+		GROUP BY payment_method
+	`, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var method string
+		var amount int
+		if err := rows.Scan(&method, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		totalFor(method).Repayments += amount
+		report.Repayments += amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.PaymentTotals = []models.PaymentMethodTotal{}
+	for _, method := range order {
+		t := totals[method]
+		t.Net = t.Sales - t.Refunds + t.Repayments
+		report.PaymentTotals = append(report.PaymentTotals, *t)
+	}
+
+	report.ExpectedCash = shift.OpeningFloat + totals[models.PaymentCash].Net
+	return report, nil
+}
+
+func (repo *ShiftRepository) getShift(q queryer, query string, args ...interface{}) (*models.Shift, error) {
+	var shift models.Shift
+	err := repo.scanShift(q.QueryRow(query, args...), &shift)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("shift.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (repo *ShiftRepository) scanShift(row rowScanner, s *models.Shift) error {
+	var closedAt sql.NullTime
+	var countedCash, zNumber sql.NullInt64
+
+	err := row.Scan(
+		&s.ID,
+		&s.Status,
+		&s.OpenedBy,
+		&s.OpeningFloat,
+		&s.OpenedAt,
+		&s.ClosedBy,
+		&closedAt,
+		&countedCash,
+		&zNumber,
+		&s.Note,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.OpenedAt = s.OpenedAt.In(repo.calendar.Location)
+	if closedAt.Valid {
+		t := closedAt.Time.In(repo.calendar.Location)
+		s.ClosedAt = &t
+	}
+	s.CountedCash = nullIntPtr(countedCash)
+	s.ZNumber = nullIntPtr(zNumber)
+	return nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// StockMovementRepository reads the stock movement ledger
+type StockMovementRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewStockMovementRepository creates a new instance of StockMovementRepository
+func NewStockMovementRepository(db *sql.DB, calendar *clock.Calendar) *StockMovementRepository {
+	return &StockMovementRepository{db: db, calendar: calendar}
+}
+
+// =======================
+// GET MOVEMENTS BY PRODUCT
+// =======================
+func (repo *StockMovementRepository) GetByProduct(productID int) ([]models.StockMovement, error) {
+	rows, err := repo.db.Query(`
+		SELECT id, product_id, type, quantity, stock_after, unit_cost, unit_price, reference, created_at
+		FROM stock_movements
+		WHERE product_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		var stockAfter, unitCost, unitPrice sql.NullInt64
+		err := rows.Scan(
+			&m.ID,
+			&m.ProductID,
+			&m.Type,
+			&m.Quantity,
+			&stockAfter,
+			&unitCost,
+			&unitPrice,
+			&m.Reference,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		m.StockAfter = nullIntPtr(stockAfter)
+		m.UnitCost = nullIntPtr(unitCost)
+		m.UnitPrice = nullIntPtr(unitPrice)
+		m.CreatedAt = m.CreatedAt.In(repo.calendar.Location)
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// recordStockMovement appends a movement to the ledger. It must run after
+// the product row was updated: the resulting stock, cost and price are
+// copied from the product.
+func recordStockMovement(tx *sql.Tx, productID int, movementType string, quantity int, reference string, at time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO stock_movements
+		(product_id, type, quantity, stock_after, unit_cost, unit_price, reference, created_at)
+		SELECT id, ?, ?, stock, cost, price, ?, ?
+		FROM products
+		WHERE id = ?
+	`, movementType, quantity, reference, clock.ToDB(at), productID)
+	return err
+}
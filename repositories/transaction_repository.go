@@ -3,19 +3,25 @@ package repositories
 import (
 	"database/sql"
 	"fmt"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
 	"task-crud-kategori/models"
+	"task-crud-kategori/validate"
+	"time"
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	calendar *clock.Calendar
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, calendar *clock.Calendar) *TransactionRepository {
+	return &TransactionRepository{db: db, calendar: calendar}
 }
 
 func (repo *TransactionRepository) CreateTransaction(
-	items []models.CheckoutItem,
+	req models.CheckoutRequest,
+	audit AuditFunc,
 ) (*models.Transaction, error) {
 
 	tx, err := repo.db.Begin()
@@ -24,27 +30,76 @@ func (repo *TransactionRepository) CreateTransaction(
 	}
 	defer tx.Rollback()
 
+	transaction, err := createTransaction(tx, repo.calendar, req, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := audit(tx, transaction.ID, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// createTransaction books a sale inside tx. Stock reserved by parked
+// carts is not for sale, except what cartID itself reserved when a cart
+// is being checked out.
+func createTransaction(
+	tx *sql.Tx,
+	calendar *clock.Calendar,
+	req models.CheckoutRequest,
+	cartID int,
+) (*models.Transaction, error) {
+
+	if req.CustomerID != nil {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM customers WHERE id = ?", *req.CustomerID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, apperror.NotFound("customer.id_not_found", *req.CustomerID).With("customer_id", *req.CustomerID)
+		}
+	}
+
 	totalAmount := 0
 	details := []models.TransactionDetail{}
+	categories := map[int]int{} // product id -> category id, for the rollups
 
-	for _, item := range items {
-		var productPrice, stock int
+	for _, item := range req.Items {
+		var productPrice, stock, categoryID int
 		var productName string
 
 		err := tx.QueryRow(
-			"SELECT name, price, stock FROM products WHERE id = ?",
+			"SELECT name, price, stock, IFNULL(category_id, 0) FROM products WHERE id = ?",
 			item.ProductID,
-		).Scan(&productName, &productPrice, &stock)
+		).Scan(&productName, &productPrice, &stock, &categoryID)
 
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+			return nil, apperror.NotFound("product.id_not_found", item.ProductID).With("product_id", item.ProductID)
+		}
+		if err != nil {
+			return nil, err
 		}
+
+		reserved, err := reservedStock(tx, item.ProductID, cartID)
 		if err != nil {
 			return nil, err
 		}
+		if stock-reserved < item.Quantity {
+			return nil, apperror.InsufficientStock(item.ProductID, productName, stock-reserved, item.Quantity)
+		}
 
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("stock not enough for product %s", productName)
+		// the handler has checked the user may override prices
+		if item.Price != nil {
+			if *item.Price < 0 {
+				return nil, apperror.Validation("product.negative_price", productName)
+			}
+			productPrice = *item.Price
 		}
 
 		subtotal := productPrice * item.Quantity
@@ -59,6 +114,7 @@ func (repo *TransactionRepository) CreateTransaction(
 			return nil, err
 		}
 
+		categories[item.ProductID] = categoryID
 		details = append(details, models.TransactionDetail{
 			ProductID:   item.ProductID,
 			ProductName: productName,
@@ -67,10 +123,68 @@ func (repo *TransactionRepository) CreateTransaction(
 		})
 	}
 
+	if req.Discount > totalAmount {
+		return nil, validate.Field("discount", validate.OutOfRange, "checkout.discount_exceeds_total")
+	}
+
+	// timestamp always stored in UTC
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	// a voucher comes off after the manual discount
+	discount := req.Discount
+	var err error
+	var voucher *voucherCheckout
+	if req.VoucherCode != "" {
+		businessDate := calendar.DateOf(createdAt).Format(clock.DateLayout)
+		voucher, err = startVoucher(tx, req.VoucherCode, req.CustomerID, details, categories, totalAmount-discount, businessDate)
+		if err != nil {
+			return nil, err
+		}
+		discount += voucher.discount
+	}
+
+	// members get their tier discount and may pay part with points
+	var loyalty *loyaltyCheckout
+	if req.CustomerID != nil {
+		loyalty, err = startLoyalty(tx, *req.CustomerID, totalAmount, discount, req.RedeemPoints, createdAt)
+		if err != nil {
+			return nil, err
+		}
+		discount += loyalty.discount()
+	} else if req.RedeemPoints > 0 {
+		return nil, validate.Field("customer_id", validate.Required, "checkout.points_need_customer")
+	}
+	totalAmount -= discount
+
+	// kasbon is only for customers with room under their credit limit
+	if req.PaymentMethod == models.PaymentCredit {
+		if req.CustomerID == nil {
+			return nil, validate.Field("customer_id", validate.Required, "checkout.credit_needs_customer")
+		}
+		if err := checkCreditLimit(tx, *req.CustomerID, totalAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	// sales are booked on the open register shift, if any
+	shiftID, err := openShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	// INSERT transaction (SQLite way)
 	res, err := tx.Exec(
-		"INSERT INTO transactions (total_amount) VALUES (?)",
+		`INSERT INTO transactions
+		(total_amount, discount_amount, payment_method, shift_id, customer_id, user_id, approved_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		totalAmount,
+		discount,
+		req.PaymentMethod,
+		shiftID,
+		req.CustomerID,
+		req.UserID,
+		req.ApprovedBy,
+		clock.ToDB(createdAt),
 	)
 	if err != nil {
 		return nil, err
@@ -85,7 +199,7 @@ func (repo *TransactionRepository) CreateTransaction(
 	for i := range details {
 		details[i].TransactionID = transactionID
 
-		_, err = tx.Exec(
+		res, err := tx.Exec(
 			`INSERT INTO transaction_details 
 			(transaction_id, product_id, quantity, subtotal)
 			VALUES (?, ?, ?, ?)`,
@@ -97,15 +211,412 @@ func (repo *TransactionRepository) CreateTransaction(
 		if err != nil {
 			return nil, err
 		}
+
+		detailID, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		details[i].ID = int(detailID)
+
+		err = recordStockMovement(
+			tx,
+			details[i].ProductID,
+			models.MovementSale,
+			-details[i].Quantity,
+			fmt.Sprintf("transaction:%d", transactionID),
+			createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var redemption *models.VoucherRedemption
+	if voucher != nil {
+		redemption, err = voucher.redeem(tx, transactionID, req.CustomerID, createdAt)
+		if err != nil {
+			return nil, err
+		}
+		redemption.CreatedAt = createdAt.In(calendar.Location)
+	}
+
+	if loyalty != nil {
+		if err := loyalty.book(tx, transactionID, details, categories, totalAmount, createdAt); err != nil {
+			return nil, err
+		}
+	}
+
+	// keep the daily rollups in step with the sale
+	date := calendar.DateOf(createdAt).Format(clock.DateLayout)
+	delta := newRollupSet()
+	delta.addSale(date, totalAmount, discount, req.PaymentMethod)
+	for _, d := range details {
+		delta.addSaleLine(date, d.ProductID, categories[d.ProductID], d.Quantity, d.Subtotal)
+	}
+	if err := applyRollupDelta(tx, delta); err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		ID:             transactionID,
+		TotalAmount:    totalAmount,
+		DiscountAmount: discount,
+		PaymentMethod:  req.PaymentMethod,
+		ShiftID:        shiftID,
+		CustomerID:     req.CustomerID,
+		UserID:         req.UserID,
+		ApprovedBy:     req.ApprovedBy,
+		CreatedAt:      createdAt.In(calendar.Location),
+		BusinessDate:   calendar.DateOf(createdAt).Format(clock.DateLayout),
+		Details:        details,
+		Voucher:        redemption,
+	}
+	if loyalty != nil {
+		transaction.Loyalty = &loyalty.summary
+	}
+	return transaction, nil
+}
+
+// =======================
+// GET ALL TRANSACTIONS
+// =======================
+// GetAll lists the transactions of the business days from startDate to
+// endDate inclusive, newest first, without details. Zero dates mean today.
+func (repo *TransactionRepository) GetAll(startDate, endDate time.Time) ([]models.Transaction, error) {
+	if startDate.IsZero() || endDate.IsZero() {
+		startDate = repo.calendar.Today()
+		endDate = startDate
+	}
+	from, to := repo.calendar.Bounds(startDate, endDate)
+
+	rows, err := repo.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		LEFT JOIN refunds r ON r.transaction_id = t.id
+		WHERE t.created_at >= ? AND t.created_at < ?
+		ORDER BY t.created_at DESC, t.id DESC
+	`, clock.ToDB(from), clock.ToDB(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		repo.localize(&t)
+		t.Details = []models.TransactionDetail{}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// =======================
+// GET TRANSACTION BY ID
+// =======================
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
+	err := scanTransaction(repo.db.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		LEFT JOIN refunds r ON r.transaction_id = t.id
+		WHERE t.id = ?
+	`, id), &t)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("transaction.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	repo.localize(&t)
+
+	rows, err := repo.db.Query(`
+		SELECT td.id, td.transaction_id, td.product_id, IFNULL(p.name, ''), td.quantity, td.subtotal
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+		WHERE td.transaction_id = ?
+		ORDER BY td.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t.Details = []models.TransactionDetail{}
+	for rows.Next() {
+		var d models.TransactionDetail
+		err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		t.Details = append(t.Details, d)
+	}
+
+	return &t, rows.Err()
+}
+
+// =======================
+// REFUND TRANSACTION
+// =======================
+// Refund reverses a whole transaction: the items go back in stock and the
+// amount is paid back with the original payment method. A transaction can
+// only be refunded once. The refund is audited on the transaction.
+func (repo *TransactionRepository) Refund(transactionID int, req models.RefundRequest, audit AuditFunc) (*models.Refund, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refund := &models.Refund{
+		TransactionID: transactionID,
+		Reason:        req.Reason,
+		UserID:        req.UserID,
+		ApprovedBy:    req.ApprovedBy,
+	}
+
+	var refunded int
+	err = tx.QueryRow(`
+		SELECT t.total_amount, t.payment_method, COUNT(r.id)
+		FROM transactions t
+		LEFT JOIN refunds r ON r.transaction_id = t.id
+		WHERE t.id = ?
+		GROUP BY t.id
+	`, transactionID).Scan(&refund.Amount, &refund.PaymentMethod, &refunded)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("transaction.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if refunded > 0 {
+		return nil, apperror.Conflict("transaction.already_refunded")
+	}
+
+	// put the items back in stock
+	_, err = tx.Exec(`
+		UPDATE products
+		SET stock = stock + (
+			SELECT SUM(td.quantity)
+			FROM transaction_details td
+			WHERE td.transaction_id = ? AND td.product_id = products.id
+		)
+		WHERE id IN (
+			SELECT product_id FROM transaction_details WHERE transaction_id = ?
+		)
+	`, transactionID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// refunds are booked on the shift that pays them out
+	refund.ShiftID, err = openShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.Exec(`
+		INSERT INTO refunds
+		(transaction_id, amount, payment_method, reason, shift_id, user_id, approved_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, transactionID, refund.Amount, refund.PaymentMethod, refund.Reason, refund.ShiftID,
+		refund.UserID, refund.ApprovedBy, clock.ToDB(createdAt))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.recordRefundMovements(tx, refund, int(id), createdAt); err != nil {
+		return nil, err
+	}
+
+	if err := reverseLoyalty(tx, transactionID, int(id), createdAt); err != nil {
+		return nil, err
+	}
+
+	if err := reverseVoucher(tx, transactionID, createdAt); err != nil {
+		return nil, err
+	}
+
+	if err := repo.applyRefundRollup(tx, refund, createdAt); err != nil {
+		return nil, err
+	}
+
+	refund.ID = int(id)
+	refund.CreatedAt = createdAt.In(repo.calendar.Location)
+	if err := audit(tx, transactionID, refund); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	return refund, nil
+}
+
+// recordRefundMovements puts the returned items on the stock ledger
+func (repo *TransactionRepository) recordRefundMovements(tx *sql.Tx, refund *models.Refund, refundID int, createdAt time.Time) error {
+	rows, err := tx.Query(
+		"SELECT product_id, quantity FROM transaction_details WHERE transaction_id = ?",
+		refund.TransactionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	type line struct{ productID, quantity int }
+	lines := []line{}
+	for rows.Next() {
+		var l line
+		if err := rows.Scan(&l.productID, &l.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		err := recordStockMovement(tx, l.productID, models.MovementRefund, l.quantity, fmt.Sprintf("refund:%d", refundID), createdAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRefundRollup books a refund on the rollups of the day it is paid out
+func (repo *TransactionRepository) applyRefundRollup(tx *sql.Tx, refund *models.Refund, createdAt time.Time) error {
+	date := repo.calendar.DateOf(createdAt).Format(clock.DateLayout)
+	delta := newRollupSet()
+	delta.addRefund(date, refund.Amount, refund.PaymentMethod)
+
+	rows, err := tx.Query(`
+		SELECT td.product_id, IFNULL(p.category_id, 0), td.quantity, td.subtotal
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+		WHERE td.transaction_id = ?
+	`, refund.TransactionID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var productID, categoryID, quantity, subtotal int
+		if err := rows.Scan(&productID, &categoryID, &quantity, &subtotal); err != nil {
+			rows.Close()
+			return err
+		}
+		delta.addRefundLine(date, productID, categoryID, quantity, subtotal)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return applyRollupDelta(tx, delta)
+}
+
+// transactionColumns is the select list read by scanTransaction, for
+// transactions aliased t joined with refunds aliased r
+const transactionColumns = `
+	t.id, t.total_amount, t.discount_amount, t.payment_method, t.shift_id,
+	t.customer_id, t.user_id, t.approved_by, r.id IS NOT NULL, t.created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner, t *models.Transaction) error {
+	var shiftID, customerID, userID, approvedBy sql.NullInt64
+	err := row.Scan(
+		&t.ID,
+		&t.TotalAmount,
+		&t.DiscountAmount,
+		&t.PaymentMethod,
+		&shiftID,
+		&customerID,
+		&userID,
+		&approvedBy,
+		&t.Refunded,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	t.ShiftID = nullIntPtr(shiftID)
+	t.CustomerID = nullIntPtr(customerID)
+	t.UserID = nullIntPtr(userID)
+	t.ApprovedBy = nullIntPtr(approvedBy)
+	return nil
+}
+
+// openShiftID returns the id of the open register shift, or nil
+func openShiftID(tx *sql.Tx) (*int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM shifts WHERE status = ?", models.ShiftOpen).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+// localize converts the stored UTC timestamp to the store timezone
+func (repo *TransactionRepository) localize(t *models.Transaction) {
+	t.CreatedAt = t.CreatedAt.In(repo.calendar.Location)
+	t.BusinessDate = repo.calendar.DateOf(t.CreatedAt).Format(clock.DateLayout)
+}
+
+// =======================
+// GET TRANSACTIONS BY CUSTOMER
+// =======================
+// GetByCustomer lists every transaction of a customer, newest first,
+// without details
+func (repo *TransactionRepository) GetByCustomer(customerID int) ([]models.Transaction, error) {
+	rows, err := repo.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		LEFT JOIN refunds r ON r.transaction_id = t.id
+		WHERE t.customer_id = ?
+		ORDER BY t.created_at DESC, t.id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		repo.localize(&t)
+		t.Details = []models.TransactionDetail{}
+		transactions = append(transactions, t)
+	}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
-	}, nil
+	return transactions, rows.Err()
 }
@@ -0,0 +1,460 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// UserRepository handles staff accounts and their sign-in sessions
+type UserRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewUserRepository creates a new instance of UserRepository
+func NewUserRepository(db *sql.DB, calendar *clock.Calendar) *UserRepository {
+	return &UserRepository{db: db, calendar: calendar}
+}
+
+const userColumns = "id, username, name, role, pin_hash <> '', active, created_at, updated_at"
+
+// =======================
+// GET ALL USERS
+// =======================
+func (repo *UserRepository) GetAll() ([]models.User, error) {
+	rows, err := repo.db.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := repo.scanUser(rows, &u); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// =======================
+// COUNT USERS
+// =======================
+func (repo *UserRepository) Count() (int, error) {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// =======================
+// CREATE USER
+// =======================
+func (repo *UserRepository) Create(user *models.User, passwordHash string) error {
+	if err := repo.checkUsername(user.Username, 0); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := repo.db.Exec(`
+		INSERT INTO users (username, name, role, password_hash, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, user.Username, user.Name, user.Role, passwordHash, user.Active, clock.ToDB(now), clock.ToDB(now))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	user.ID = int(id)
+	user.HasPIN = false
+	user.CreatedAt = now.In(repo.calendar.Location)
+	user.UpdatedAt = user.CreatedAt
+	return nil
+}
+
+// =======================
+// GET USER BY ID
+// =======================
+func (repo *UserRepository) GetByID(id int) (*models.User, error) {
+	var u models.User
+	err := repo.scanUser(repo.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id), &u)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("user.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// =======================
+// GET CREDENTIALS
+// =======================
+// GetCredentials returns a user by username with their password hash
+func (repo *UserRepository) GetCredentials(username string) (*models.User, string, error) {
+	return repo.getWithSecret(username, "password_hash")
+}
+
+// GetApprover returns a user by username with their supervisor PIN hash
+func (repo *UserRepository) GetApprover(username string) (*models.User, string, error) {
+	return repo.getWithSecret(username, "pin_hash")
+}
+
+func (repo *UserRepository) getWithSecret(username, column string) (*models.User, string, error) {
+	var u models.User
+	var secret string
+	err := repo.db.QueryRow(
+		"SELECT "+userColumns+", "+column+" FROM users WHERE username = ?", username,
+	).Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.HasPIN, &u.Active, &u.CreatedAt, &u.UpdatedAt, &secret)
+	if err == sql.ErrNoRows {
+		return nil, "", apperror.NotFound("user.not_found")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	u.CreatedAt = u.CreatedAt.In(repo.calendar.Location)
+	u.UpdatedAt = u.UpdatedAt.In(repo.calendar.Location)
+	return &u, secret, nil
+}
+
+// GetPasswordHash returns the password hash of a user
+func (repo *UserRepository) GetPasswordHash(id int) (string, error) {
+	var hash string
+	err := repo.db.QueryRow("SELECT password_hash FROM users WHERE id = ?", id).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", apperror.NotFound("user.not_found")
+	}
+	return hash, err
+}
+
+// =======================
+// UPDATE USER
+// =======================
+// Update changes the username, name, role and status of a user.
+// Deactivating a user signs them out everywhere.
+func (repo *UserRepository) Update(user *models.User) error {
+	if err := repo.checkUsername(user.Username, user.ID); err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := tx.Exec(`
+		UPDATE users SET username = ?, name = ?, role = ?, active = ?, updated_at = ?
+		WHERE id = ?
+	`, user.Username, user.Name, user.Role, user.Active, clock.ToDB(now), user.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("user.not_found")
+	}
+
+	if !user.Active {
+		if err := revokeUserSessions(tx, user.ID, 0, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	user.UpdatedAt = now.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// SET PASSWORD
+// =======================
+// SetPassword replaces the password hash of a user and signs out every
+// session except keepSession (0 signs out all)
+func (repo *UserRepository) SetPassword(id int, passwordHash string, keepSession int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := tx.Exec(
+		"UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?",
+		passwordHash, clock.ToDB(now), id,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("user.not_found")
+	}
+
+	if err := revokeUserSessions(tx, id, keepSession, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================
+// SET PIN
+// =======================
+// SetPIN replaces the supervisor PIN hash of a user
+func (repo *UserRepository) SetPIN(id int, pinHash string) error {
+	result, err := repo.db.Exec(
+		"UPDATE users SET pin_hash = ?, pin_failures = 0, pin_locked_until = NULL, updated_at = ? WHERE id = ?",
+		pinHash, clock.ToDB(time.Now().UTC().Truncate(time.Second)), id,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("user.not_found")
+	}
+	return nil
+}
+
+// =======================
+// PIN LOCKOUT
+// =======================
+// PINLockedUntil returns when the locked PIN of a user can be used again,
+// or nil when it is not locked
+func (repo *UserRepository) PINLockedUntil(id int) (*time.Time, error) {
+	var until sql.NullTime
+	err := repo.db.QueryRow(
+		"SELECT pin_locked_until FROM users WHERE id = ? AND pin_locked_until > ?",
+		id, clock.ToDB(time.Now()),
+	).Scan(&until)
+	if err == sql.ErrNoRows || !until.Valid {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := until.Time.In(repo.calendar.Location)
+	return &t, nil
+}
+
+// RecordPINFailure counts a wrong PIN. The maxFailures-th in a row locks
+// the PIN for lockout and starts the count again; it returns until when,
+// or nil while the PIN is not locked.
+func (repo *UserRepository) RecordPINFailure(id, maxFailures int, lockout time.Duration) (*time.Time, error) {
+	var failures int
+	err := repo.db.QueryRow(
+		"UPDATE users SET pin_failures = pin_failures + 1 WHERE id = ? RETURNING pin_failures", id,
+	).Scan(&failures)
+	if err != nil || failures < maxFailures {
+		return nil, err
+	}
+
+	until := time.Now().Add(lockout).Truncate(time.Second).In(repo.calendar.Location)
+	_, err = repo.db.Exec(
+		"UPDATE users SET pin_failures = 0, pin_locked_until = ? WHERE id = ?",
+		clock.ToDB(until), id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &until, nil
+}
+
+// ResetPINFailures clears the count after a good PIN
+func (repo *UserRepository) ResetPINFailures(id int) error {
+	_, err := repo.db.Exec("UPDATE users SET pin_failures = 0 WHERE id = ? AND pin_failures > 0", id)
+	return err
+}
+
+// =======================
+// DELETE USER
+// =======================
+// Delete removes a user who never rang up or approved a sale or refund;
+// anyone else can only be deactivated
+func (repo *UserRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var used int
+	err = tx.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM transactions WHERE user_id = ? OR approved_by = ?)
+			+ (SELECT COUNT(*) FROM refunds WHERE user_id = ? OR approved_by = ?)
+	`, id, id, id, id).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return apperror.Conflict("user.has_transactions")
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_sessions WHERE user_id = ?", id); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("user.not_found")
+	}
+	return tx.Commit()
+}
+
+// =======================
+// CREATE SESSION
+// =======================
+// CreateSession records a sign-in
+func (repo *UserRepository) CreateSession(userID int, refreshHash string, expiresAt time.Time) (*models.Session, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := repo.db.Exec(`
+		INSERT INTO user_sessions (user_id, refresh_hash, expires_at, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, refreshHash, clock.ToDB(expiresAt), clock.ToDB(now), clock.ToDB(now))
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &models.Session{ID: int(id), UserID: userID, ExpiresAt: expiresAt.In(repo.calendar.Location)}, nil
+}
+
+// =======================
+// GET SESSION USER
+// =======================
+// GetSessionUser returns the user of a live session: not revoked, not
+// expired and belonging to an active user
+func (repo *UserRepository) GetSessionUser(sessionID, userID int) (*models.User, error) {
+	var u models.User
+	err := repo.scanUser(repo.db.QueryRow(`
+		SELECT u.id, u.username, u.name, u.role, u.pin_hash <> '', u.active, u.created_at, u.updated_at
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = ? AND s.user_id = ? AND s.revoked_at IS NULL
+		AND s.expires_at > ? AND u.active = 1
+	`, sessionID, userID, clock.ToDB(time.Now().UTC())), &u)
+	if err == sql.ErrNoRows {
+		return nil, apperror.Unauthorized("auth.session_expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// =======================
+// ROTATE SESSION
+// =======================
+// RotateSession swaps the refresh token of a live session for a new one
+// and extends it to expiresAt. The old refresh token stops working.
+func (repo *UserRepository) RotateSession(refreshHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var session models.Session
+	err = tx.QueryRow(`
+		SELECT s.id, s.user_id
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.refresh_hash = ? AND s.revoked_at IS NULL
+		AND s.expires_at > ? AND u.active = 1
+	`, refreshHash, clock.ToDB(now)).Scan(&session.ID, &session.UserID)
+	if err == sql.ErrNoRows {
+		return nil, apperror.Unauthorized("auth.invalid_refresh_token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_sessions SET refresh_hash = ?, expires_at = ?, last_used_at = ?
+		WHERE id = ?
+	`, newHash, clock.ToDB(expiresAt), clock.ToDB(now), session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = expiresAt.In(repo.calendar.Location)
+	return &session, nil
+}
+
+// =======================
+// REVOKE SESSION
+// =======================
+func (repo *UserRepository) RevokeSession(sessionID int) error {
+	_, err := repo.db.Exec(
+		"UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		clock.ToDB(time.Now().UTC().Truncate(time.Second)), sessionID,
+	)
+	return err
+}
+
+func (repo *UserRepository) checkUsername(username string, exceptID int) error {
+	var taken int
+	err := repo.db.QueryRow(
+		"SELECT COUNT(*) FROM users WHERE username = ? AND id <> ?", username, exceptID,
+	).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperror.Conflict("user.username_taken")
+	}
+	return nil
+}
+
+func (repo *UserRepository) scanUser(row rowScanner, u *models.User) error {
+	if err := row.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.HasPIN, &u.Active, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return err
+	}
+	u.CreatedAt = u.CreatedAt.In(repo.calendar.Location)
+	u.UpdatedAt = u.UpdatedAt.In(repo.calendar.Location)
+	return nil
+}
+
+// revokeUserSessions signs a user out of every session but keepSession
+func revokeUserSessions(tx *sql.Tx, userID, keepSession int, at time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE user_sessions SET revoked_at = ?
+		WHERE user_id = ? AND id <> ? AND revoked_at IS NULL
+	`, clock.ToDB(at), userID, keepSession)
+	return err
+}
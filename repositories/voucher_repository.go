@@ -0,0 +1,503 @@
+package repositories
+
+import (
+	"database/sql"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"time"
+)
+
+// VoucherRepository handles database operations for vouchers. Vouchers
+// are redeemed inside the checkout; see startVoucher.
+type VoucherRepository struct {
+	db       *sql.DB
+	calendar *clock.Calendar
+}
+
+// NewVoucherRepository creates a new instance of VoucherRepository
+func NewVoucherRepository(db *sql.DB, calendar *clock.Calendar) *VoucherRepository {
+	return &VoucherRepository{db: db, calendar: calendar}
+}
+
+const voucherColumns = `
+	id, code, description, type, value, max_discount, min_spend,
+	valid_from, valid_until, usage_limit, per_customer_limit, times_used,
+	active, created_at`
+
+// =======================
+// GET ALL VOUCHERS
+// =======================
+func (repo *VoucherRepository) GetAll() ([]models.Voucher, error) {
+	rows, err := repo.db.Query("SELECT " + voucherColumns + " FROM vouchers ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	vouchers := []models.Voucher{}
+	for rows.Next() {
+		var v models.Voucher
+		if err := repo.scanVoucher(rows, &v); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		vouchers = append(vouchers, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range vouchers {
+		if err := loadVoucherRestrictions(repo.db, &vouchers[i]); err != nil {
+			return nil, err
+		}
+	}
+	return vouchers, nil
+}
+
+// =======================
+// CREATE VOUCHER
+// =======================
+func (repo *VoucherRepository) Create(voucher *models.Voucher) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taken int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM vouchers WHERE code = ?", voucher.Code).Scan(&taken); err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperror.Conflict("voucher.code_taken")
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.Exec(`
+		INSERT INTO vouchers
+		(code, description, type, value, max_discount, min_spend, valid_from, valid_until,
+		 usage_limit, per_customer_limit, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		voucher.Code, voucher.Description, voucher.Type, voucher.Value,
+		voucher.MaxDiscount, voucher.MinSpend, voucher.ValidFrom, voucher.ValidUntil,
+		voucher.UsageLimit, voucher.PerCustomerLimit, voucher.Active, clock.ToDB(createdAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := saveVoucherRestrictions(tx, int(id), voucher); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	voucher.ID = int(id)
+	voucher.TimesUsed = 0
+	voucher.CreatedAt = createdAt.In(repo.calendar.Location)
+	return nil
+}
+
+// =======================
+// GET VOUCHER BY ID
+// =======================
+func (repo *VoucherRepository) GetByID(id int) (*models.Voucher, error) {
+	var v models.Voucher
+	err := repo.scanVoucher(repo.db.QueryRow("SELECT "+voucherColumns+" FROM vouchers WHERE id = ?", id), &v)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("voucher.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadVoucherRestrictions(repo.db, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// =======================
+// UPDATE VOUCHER
+// =======================
+// Update changes the terms of a voucher; the code and the number of times
+// it was used stay as they are
+func (repo *VoucherRepository) Update(voucher *models.Voucher) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE vouchers
+		SET description = ?, type = ?, value = ?, max_discount = ?, min_spend = ?,
+			valid_from = ?, valid_until = ?, usage_limit = ?, per_customer_limit = ?, active = ?
+		WHERE id = ?
+	`,
+		voucher.Description, voucher.Type, voucher.Value, voucher.MaxDiscount, voucher.MinSpend,
+		voucher.ValidFrom, voucher.ValidUntil, voucher.UsageLimit, voucher.PerCustomerLimit,
+		voucher.Active, voucher.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("voucher.not_found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM voucher_products WHERE voucher_id = ?", voucher.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM voucher_categories WHERE voucher_id = ?", voucher.ID); err != nil {
+		return err
+	}
+	if err := saveVoucherRestrictions(tx, voucher.ID, voucher); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	updated, err := repo.GetByID(voucher.ID)
+	if err != nil {
+		return err
+	}
+	*voucher = *updated
+	return nil
+}
+
+// =======================
+// DELETE VOUCHER
+// =======================
+// Delete removes a voucher that was never redeemed; used vouchers are
+// kept for their redemptions and can be deactivated instead
+func (repo *VoucherRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var redeemed int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM voucher_redemptions WHERE voucher_id = ?", id).Scan(&redeemed); err != nil {
+		return err
+	}
+	if redeemed > 0 {
+		return apperror.Conflict("voucher.redeemed")
+	}
+
+	if _, err := tx.Exec("DELETE FROM voucher_products WHERE voucher_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM voucher_categories WHERE voucher_id = ?", id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM vouchers WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperror.NotFound("voucher.not_found")
+	}
+
+	return tx.Commit()
+}
+
+// =======================
+// GET REDEMPTIONS
+// =======================
+func (repo *VoucherRepository) GetRedemptions(voucherID int) ([]models.VoucherRedemption, error) {
+	if _, err := repo.GetByID(voucherID); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT vr.id, vr.voucher_id, v.code, vr.transaction_id, vr.customer_id, vr.amount, vr.created_at, vr.reversed_at
+		FROM voucher_redemptions vr
+		JOIN vouchers v ON v.id = vr.voucher_id
+		WHERE vr.voucher_id = ?
+		ORDER BY vr.id DESC
+	`, voucherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.VoucherRedemption{}
+	for rows.Next() {
+		var r models.VoucherRedemption
+		var customerID sql.NullInt64
+		var reversedAt sql.NullTime
+		err := rows.Scan(&r.ID, &r.VoucherID, &r.Code, &r.TransactionID, &customerID, &r.Amount, &r.CreatedAt, &reversedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		r.CustomerID = nullIntPtr(customerID)
+		r.CreatedAt = r.CreatedAt.In(repo.calendar.Location)
+		if reversedAt.Valid {
+			t := reversedAt.Time.In(repo.calendar.Location)
+			r.ReversedAt = &t
+		}
+		redemptions = append(redemptions, r)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// voucherCheckout is a voucher accepted for a checkout, not yet redeemed
+type voucherCheckout struct {
+	voucher  models.Voucher
+	discount int
+}
+
+// startVoucher checks a voucher code against a checkout and works out its
+// discount. due is what is left to pay after the manual discount.
+func startVoucher(tx *sql.Tx, code string, customerID *int, details []models.TransactionDetail, categories map[int]int, due int, businessDate string) (*voucherCheckout, error) {
+	var v models.Voucher
+	err := scanVoucherRow(tx.QueryRow("SELECT "+voucherColumns+" FROM vouchers WHERE code = ?", code), &v)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("voucher.not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadVoucherRestrictions(tx, &v); err != nil {
+		return nil, err
+	}
+
+	if !v.Active {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.inactive")
+	}
+	if v.ValidFrom != nil && businessDate < *v.ValidFrom {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.not_started")
+	}
+	if v.ValidUntil != nil && businessDate > *v.ValidUntil {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.expired")
+	}
+	if v.UsageLimit > 0 && v.TimesUsed >= v.UsageLimit {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.used_up")
+	}
+
+	if v.PerCustomerLimit > 0 {
+		if customerID == nil {
+			return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.needs_customer")
+		}
+		var used int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM voucher_redemptions
+			WHERE voucher_id = ? AND customer_id = ? AND reversed_at IS NULL
+		`, v.ID, *customerID).Scan(&used)
+		if err != nil {
+			return nil, err
+		}
+		if used >= v.PerCustomerLimit {
+			return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.customer_limit")
+		}
+	}
+
+	products := map[int]bool{}
+	for _, id := range v.ProductIDs {
+		products[id] = true
+	}
+	cats := map[int]bool{}
+	for _, id := range v.CategoryIDs {
+		cats[id] = true
+	}
+	restricted := len(products) > 0 || len(cats) > 0
+
+	eligible := 0
+	for _, d := range details {
+		if !restricted || products[d.ProductID] || cats[categories[d.ProductID]] {
+			eligible += d.Subtotal
+		}
+	}
+	if eligible == 0 {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.not_applicable_products")
+	}
+	if eligible < v.MinSpend {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.min_spend", v.MinSpend).
+			With("min_spend", v.MinSpend)
+	}
+
+	discount := v.Value
+	if v.Type == models.VoucherPercent {
+		discount = eligible * v.Value / 100
+		if v.MaxDiscount > 0 {
+			discount = min(discount, v.MaxDiscount)
+		}
+	}
+	discount = min(discount, eligible, due)
+
+	return &voucherCheckout{voucher: v, discount: discount}, nil
+}
+
+// redeem takes one use of the voucher and records the redemption. The
+// conditional update fails when a concurrent checkout took the last use.
+func (vc *voucherCheckout) redeem(tx *sql.Tx, transactionID int, customerID *int, at time.Time) (*models.VoucherRedemption, error) {
+	res, err := tx.Exec(`
+		UPDATE vouchers
+		SET times_used = times_used + 1
+		WHERE id = ? AND active = 1 AND (usage_limit = 0 OR times_used < usage_limit)
+	`, vc.voucher.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, apperror.New(apperror.CodeVoucherNotApplicable, "voucher.used_up")
+	}
+
+	res, err = tx.Exec(`
+		INSERT INTO voucher_redemptions (voucher_id, transaction_id, customer_id, amount, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, vc.voucher.ID, transactionID, customerID, vc.discount, clock.ToDB(at))
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.VoucherRedemption{
+		ID:            int(id),
+		VoucherID:     vc.voucher.ID,
+		Code:          vc.voucher.Code,
+		TransactionID: transactionID,
+		CustomerID:    customerID,
+		Amount:        vc.discount,
+		CreatedAt:     at,
+	}, nil
+}
+
+// reverseVoucher gives back the voucher use of a refunded transaction
+func reverseVoucher(tx *sql.Tx, transactionID int, at time.Time) error {
+	var id, voucherID int
+	err := tx.QueryRow(
+		"SELECT id, voucher_id FROM voucher_redemptions WHERE transaction_id = ? AND reversed_at IS NULL",
+		transactionID,
+	).Scan(&id, &voucherID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE voucher_redemptions SET reversed_at = ? WHERE id = ?", clock.ToDB(at), id); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE vouchers SET times_used = times_used - 1 WHERE id = ? AND times_used > 0", voucherID)
+	return err
+}
+
+func saveVoucherRestrictions(tx *sql.Tx, voucherID int, voucher *models.Voucher) error {
+	for _, productID := range voucher.ProductIDs {
+		_, err := tx.Exec("INSERT OR IGNORE INTO voucher_products (voucher_id, product_id) VALUES (?, ?)", voucherID, productID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, categoryID := range voucher.CategoryIDs {
+		_, err := tx.Exec("INSERT OR IGNORE INTO voucher_categories (voucher_id, category_id) VALUES (?, ?)", voucherID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadVoucherRestrictions(q queryer, v *models.Voucher) error {
+	var err error
+	if v.ProductIDs, err = queryInts(q, "SELECT product_id FROM voucher_products WHERE voucher_id = ? ORDER BY product_id", v.ID); err != nil {
+		return err
+	}
+	v.CategoryIDs, err = queryInts(q, "SELECT category_id FROM voucher_categories WHERE voucher_id = ? ORDER BY category_id", v.ID)
+	return err
+}
+
+func queryInts(q queryer, query string, args ...interface{}) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []int{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func (repo *VoucherRepository) scanVoucher(row rowScanner, v *models.Voucher) error {
+	if err := scanVoucherRow(row, v); err != nil {
+		return err
+	}
+	v.CreatedAt = v.CreatedAt.In(repo.calendar.Location)
+	return nil
+}
+
+func scanVoucherRow(row rowScanner, v *models.Voucher) error {
+	var validFrom, validUntil sql.NullString
+	var createdAt sql.NullTime
+	err := row.Scan(
+		&v.ID,
+		&v.Code,
+		&v.Description,
+		&v.Type,
+		&v.Value,
+		&v.MaxDiscount,
+		&v.MinSpend,
+		&validFrom,
+		&validUntil,
+		&v.UsageLimit,
+		&v.PerCustomerLimit,
+		&v.TimesUsed,
+		&v.Active,
+		&createdAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if validFrom.Valid {
+		v.ValidFrom = &validFrom.String
+	}
+	if validUntil.Valid {
+		v.ValidUntil = &validUntil.String
+	}
+	if createdAt.Valid {
+		v.CreatedAt = createdAt.Time
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/handlers"
+	"task-crud-kategori/models"
+)
+
+// route is one entry of the route table: a "METHOD /path/{wildcard}"
+// pattern as understood by http.ServeMux, the permission it needs and
+// its handler. Routes without a permission are open to any signed in
+// user or API key, or to everyone for the public paths of RequireAuth.
+type route struct {
+	pattern    string
+	permission string
+	handler    http.HandlerFunc
+}
+
+// apiHandlers holds the handlers the route table points at
+type apiHandlers struct {
+	auth         *handlers.AuthHandler
+	users        *handlers.UserHandler
+	apiKeys      *handlers.APIKeyHandler
+	products     *handlers.ProductHandler
+	categories   *handlers.CategoryHandler
+	transactions *handlers.TransactionHandler
+	carts        *handlers.CartHandler
+	reports      *handlers.ReportHandler
+	rollups      *handlers.RollupHandler
+	forecast     *handlers.ForecastHandler
+	customers    *handlers.CustomerHandler
+	loyalty      *handlers.LoyaltyHandler
+	vouchers     *handlers.VoucherHandler
+	shifts       *handlers.ShiftHandler
+	employees    *handlers.EmployeeHandler
+	audit        *handlers.AuditHandler
+}
+
+// routes is the route table of the API
+func (h *apiHandlers) routes() []route {
+	return []route{
+		{"GET /health", "", health},
+
+		{"POST /api/auth/login", "", h.auth.Login},
+		{"POST /api/auth/refresh", "", h.auth.Refresh},
+		{"POST /api/auth/logout", "", h.auth.Logout},
+		{"GET /api/auth/me", "", h.auth.Me},
+
+		{"GET /api/users", models.PermUserManage, h.users.GetAll},
+		{"POST /api/users", models.PermUserManage, h.users.Create},
+		{"GET /api/users/{id}", models.PermUserManage, h.users.GetByID},
+		{"PUT /api/users/{id}", models.PermUserManage, h.users.Update},
+		{"DELETE /api/users/{id}", models.PermUserManage, h.users.Delete},
+		{"PUT /api/users/me/password", "", h.users.ChangePassword},
+
+		{"GET /api/api-keys", models.PermAPIKeyManage, h.apiKeys.GetAll},
+		{"POST /api/api-keys", models.PermAPIKeyManage, h.apiKeys.Create},
+		{"GET /api/api-keys/{id}", models.PermAPIKeyManage, h.apiKeys.GetByID},
+		{"DELETE /api/api-keys/{id}", models.PermAPIKeyManage, h.apiKeys.Revoke},
+		{"POST /api/api-keys/{id}/rotate", models.PermAPIKeyManage, h.apiKeys.Rotate},
+
+		{"GET /api/produk", models.PermProductRead, h.products.GetAll},
+		{"POST /api/produk", models.PermProductWrite, h.products.Create},
+		{"GET /api/produk/{id}", models.PermProductRead, h.products.GetByID},
+		{"PUT /api/produk/{id}", models.PermProductWrite, h.products.Update},
+		{"DELETE /api/produk/{id}", models.PermProductWrite, h.products.Delete},
+		{"GET /api/produk/{id}/movements", models.PermProductRead, h.products.GetMovements},
+		{"GET /api/produk/{id}/bought-together", models.PermProductRead, h.products.GetBoughtTogether},
+
+		{"GET /api/categories", models.PermCategoryRead, h.categories.GetAll},
+		{"POST /api/categories", models.PermCategoryWrite, h.categories.Create},
+		{"GET /api/categories/{id}", models.PermCategoryRead, h.categories.GetByID},
+		{"PUT /api/categories/{id}", models.PermCategoryWrite, h.categories.Update},
+		{"DELETE /api/categories/{id}", models.PermCategoryWrite, h.categories.Delete},
+		{"GET /api/categories/{id}/products", models.PermProductRead, h.products.GetByCategory},
+
+		{"POST /api/checkout", models.PermTransactionCreate, h.transactions.Checkout},
+		{"GET /api/transactions", models.PermTransactionRead, h.transactions.GetAll},
+		{"GET /api/transactions/{id}", models.PermTransactionRead, h.transactions.GetByID},
+		{"POST /api/transactions/{id}/refund", models.PermTransactionRefund, h.transactions.Refund},
+
+		{"GET /api/carts", models.PermTransactionCreate, h.carts.GetOpen},
+		{"POST /api/carts", models.PermTransactionCreate, h.carts.Create},
+		{"POST /api/carts/expire", models.PermTransactionCreate, h.carts.Expire},
+		{"GET /api/carts/{id}", models.PermTransactionCreate, h.carts.GetByID},
+		{"PUT /api/carts/{id}", models.PermTransactionCreate, h.carts.Update},
+		{"DELETE /api/carts/{id}", models.PermTransactionCreate, h.carts.Cancel},
+		{"POST /api/carts/{id}/items", models.PermTransactionCreate, h.carts.AddItem},
+		{"PUT /api/carts/{id}/items/{product_id}", models.PermTransactionCreate, h.carts.SetItem},
+		{"DELETE /api/carts/{id}/items/{product_id}", models.PermTransactionCreate, h.carts.RemoveItem},
+		{"POST /api/carts/{id}/checkout", models.PermTransactionCreate, h.carts.Checkout},
+
+		{"GET /api/report", models.PermReportRead, h.reports.GetSummary},
+		{"GET /api/report/hari-ini", models.PermReportRead, h.reports.GetSummary},
+		{"GET /api/report/timeseries", models.PermReportRead, h.reports.GetTimeSeries},
+		{"GET /api/report/heatmap", models.PermReportRead, h.reports.GetHeatmap},
+		{"GET /api/report/abc", models.PermReportRead, h.reports.GetABC},
+		{"GET /api/report/dead-stock", models.PermReportRead, h.reports.GetDeadStock},
+		{"GET /api/report/inventory-valuation", models.PermReportRead, h.reports.GetInventoryValuation},
+		{"GET /api/report/basket", models.PermReportRead, h.reports.GetBasketAnalysis},
+		{"GET /api/report/cashiers", models.PermReportRead, h.reports.GetCashiers},
+		{"GET /api/report/customers", models.PermReportRead, h.customers.GetTopCustomers},
+		{"GET /api/report/receivables-aging", models.PermReportRead, h.customers.GetReceivablesAging},
+		{"POST /api/report/rollups/rebuild", models.PermSettingsWrite, h.rollups.Rebuild},
+		{"GET /api/report/rollups/check", models.PermReportRead, h.rollups.Check},
+
+		{"GET /api/forecast", models.PermReportRead, h.forecast.GetForecast},
+		{"GET /api/forecast/backtest", models.PermReportRead, h.forecast.GetBacktest},
+
+		{"GET /api/customers", models.PermCustomerRead, h.customers.GetAll},
+		{"POST /api/customers", models.PermCustomerWrite, h.customers.Create},
+		{"GET /api/customers/{id}", models.PermCustomerRead, h.customers.GetByID},
+		{"PUT /api/customers/{id}", models.PermCustomerWrite, h.customers.Update},
+		{"DELETE /api/customers/{id}", models.PermCustomerWrite, h.customers.Delete},
+		{"GET /api/customers/{id}/transactions", models.PermCustomerRead, h.customers.GetHistory},
+		{"GET /api/customers/{id}/lifetime-value", models.PermCustomerRead, h.customers.GetLifetimeValue},
+		{"GET /api/customers/{id}/loyalty", models.PermCustomerRead, h.customers.GetLoyalty},
+		{"GET /api/customers/{id}/loyalty/ledger", models.PermCustomerRead, h.customers.GetLoyaltyLedger},
+		{"GET /api/customers/{id}/credit", models.PermCustomerRead, h.customers.GetCredit},
+		{"POST /api/customers/{id}/repayments", models.PermCustomerWrite, h.customers.Repay},
+
+		{"GET /api/loyalty/program", models.PermCustomerRead, h.loyalty.GetProgram},
+		{"PUT /api/loyalty/program", models.PermSettingsWrite, h.loyalty.UpdateProgram},
+		{"POST /api/loyalty/expire", models.PermSettingsWrite, h.loyalty.Expire},
+
+		{"GET /api/vouchers", models.PermVoucherRead, h.vouchers.GetAll},
+		{"POST /api/vouchers", models.PermVoucherWrite, h.vouchers.Create},
+		{"GET /api/vouchers/{id}", models.PermVoucherRead, h.vouchers.GetByID},
+		{"PUT /api/vouchers/{id}", models.PermVoucherWrite, h.vouchers.Update},
+		{"DELETE /api/vouchers/{id}", models.PermVoucherWrite, h.vouchers.Delete},
+		{"GET /api/vouchers/{id}/redemptions", models.PermVoucherRead, h.vouchers.GetRedemptions},
+
+		{"GET /api/shifts", models.PermShiftRead, h.shifts.GetAll},
+		{"POST /api/shifts", models.PermShiftManage, h.shifts.Open},
+		{"GET /api/shifts/current", models.PermShiftRead, h.shifts.GetCurrent},
+		{"GET /api/shifts/{id}", models.PermShiftRead, h.shifts.GetByID},
+		{"GET /api/shifts/{id}/x-report", models.PermShiftRead, h.shifts.XReport},
+		{"POST /api/shifts/{id}/close", models.PermShiftManage, h.shifts.Close},
+		{"GET /api/z-reports", models.PermShiftRead, h.shifts.GetZReports},
+		{"GET /api/z-reports/{number}", models.PermShiftRead, h.shifts.GetZReport},
+
+		{"GET /api/employees", models.PermEmployeeRead, h.employees.GetAll},
+		{"POST /api/employees", models.PermEmployeeWrite, h.employees.Create},
+		{"GET /api/employees/{id}", models.PermEmployeeRead, h.employees.GetByID},
+		{"PUT /api/employees/{id}", models.PermEmployeeWrite, h.employees.Update},
+		{"DELETE /api/employees/{id}", models.PermEmployeeWrite, h.employees.Delete},
+		{"POST /api/employees/{id}/clock-in", models.PermEmployeeWrite, h.employees.ClockIn},
+		{"POST /api/employees/{id}/clock-out", models.PermEmployeeWrite, h.employees.ClockOut},
+		{"GET /api/employees/{id}/time-entries", models.PermEmployeeRead, h.employees.GetTimesheet},
+		{"GET /api/time-clock", models.PermTimeClock, h.employees.GetTimeClock},
+		{"POST /api/time-clock/clock-in", models.PermTimeClock, h.employees.ClockInSelf},
+		{"POST /api/time-clock/clock-out", models.PermTimeClock, h.employees.ClockOutSelf},
+
+		{"GET /api/audit", models.PermAuditRead, h.audit.GetAll},
+	}
+}
+
+// newRouter registers the routes on a ServeMux, each behind its
+// permission check. Paths without a route are answered with 404 and
+// methods without one with 405 and an Allow header.
+func newRouter(authHandler *handlers.AuthHandler, routes []route) http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		handler := rt.handler
+		if rt.permission != "" {
+			handler = authHandler.Require(rt.permission, handler)
+		}
+		mux.HandleFunc(rt.pattern, handler)
+	}
+	return unmatched(mux)
+}
+
+// unmatched puts the ServeMux's own 404 and 405 answers, which are plain
+// text, in the JSON error envelope
+func unmatched(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &headerRecorder{header: http.Header{}}
+		handler.ServeHTTP(rec, r)
+		switch rec.status {
+		case http.StatusNotFound:
+			apperror.Write(w, apperror.NotFound("route.not_found"))
+		case http.StatusMethodNotAllowed:
+			allow := rec.header.Get("Allow")
+			w.Header().Set("Allow", allow)
+			apperror.Write(w, apperror.New(apperror.CodeMethodNotAllowed, "method_not_allowed").
+				With("allow", strings.Split(allow, ", ")))
+		default:
+			// redirects to the clean path
+			handler.ServeHTTP(w, r)
+		}
+	})
+}
+
+// headerRecorder keeps the header and status of a response, dropping its
+// body
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (rec *headerRecorder) Header() http.Header { return rec.header }
+
+func (rec *headerRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (rec *headerRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+// health - GET /health
+func health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "OK",
+		"message": "API Running",
+	})
+}
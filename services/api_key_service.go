@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/auth"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// APIKeyService manages API keys for scripts and devices
+type APIKeyService struct {
+	repo *repositories.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService
+func NewAPIKeyService(repo *repositories.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// GetAll lists all API keys, newest first, without their secrets
+func (s *APIKeyService) GetAll() ([]models.APIKey, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID retrieves an API key by its ID
+func (s *APIKeyService) GetByID(id int) (*models.APIKey, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create issues a new key. Its scopes must be permissions the creator
+// holds. The returned key carries the secret, which is not stored.
+func (s *APIKeyService) Create(req models.APIKeyRequest, creator *models.User) (*models.APIKey, error) {
+	req.Name = strings.TrimSpace(req.Name)
+
+	v := validate.New()
+	v.Required("name", req.Name)
+	v.Check(len(req.Scopes) > 0, "scopes", validate.Required, "apikey.scopes_required")
+	for i, scope := range req.Scopes {
+		v.Check(models.IsValidScope(scope), fmt.Sprintf("scopes[%d]", i), validate.Invalid, "apikey.invalid_scope", scope)
+	}
+	v.Check(req.ExpiresAt == nil || req.ExpiresAt.After(time.Now()), "expires_at", validate.OutOfRange,
+		"field.future", "expires_at")
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range req.Scopes {
+		if !models.HasPermission(creator.Role, scope) {
+			return nil, apperror.Forbidden("apikey.cannot_grant", scope)
+		}
+	}
+
+	secret, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		CreatedBy: &creator.ID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Create(key, auth.HashToken(secret)); err != nil {
+		return nil, err
+	}
+
+	// reload for the deduplicated, sorted scopes
+	created, err := s.repo.GetByID(key.ID)
+	if err != nil {
+		return nil, err
+	}
+	created.Key = secret
+	return created, nil
+}
+
+// Rotate gives a key a new secret and returns it
+func (s *APIKeyService) Rotate(id int) (*models.APIKey, error) {
+	secret, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rotate(id, prefix, auth.HashToken(secret)); err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	key.Key = secret
+	return key, nil
+}
+
+// Revoke stops a key from working
+func (s *APIKeyService) Revoke(id int) error {
+	return s.repo.Revoke(id)
+}
+
+// Authenticate returns the live key matching secret
+func (s *APIKeyService) Authenticate(secret string) (*models.APIKey, error) {
+	return s.repo.Authenticate(auth.HashToken(secret))
+}
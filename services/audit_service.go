@@ -0,0 +1,117 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"time"
+)
+
+// DefaultAuditLimit is how many entries GET /api/audit returns by default
+const DefaultAuditLimit = 100
+
+// MaxAuditLimit caps the entries returned in one call
+const MaxAuditLimit = 1000
+
+// AuditService records and lists data-changing calls
+type AuditService struct {
+	repo     *repositories.AuditRepository
+	calendar *clock.Calendar
+}
+
+// NewAuditService creates a new instance of AuditService
+func NewAuditService(repo *repositories.AuditRepository, calendar *clock.Calendar) *AuditService {
+	return &AuditService{repo: repo, calendar: calendar}
+}
+
+// ParseDate parses a YYYY-MM-DD business date in the store timezone
+func (s *AuditService) ParseDate(value string) (time.Time, error) {
+	return s.calendar.ParseDate(value)
+}
+
+// GetAll lists the audit entries matching filter, newest first
+func (s *AuditService) GetAll(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit > MaxAuditLimit {
+		filter.Limit = MaxAuditLimit
+	}
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && filter.EndDate.Before(filter.StartDate) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+	return s.repo.GetAll(filter)
+}
+
+// Record returns the hook that logs that actor did action on an entity,
+// keeping the fields that differ between before and the state the
+// repository saves. before is nil for a create, the saved state nil for a
+// delete. The entry is written in the transaction of the change, a failure
+// fails the change.
+func (s *AuditService) Record(actor models.Actor, action, entityType string, before interface{}) repositories.AuditFunc {
+	return func(tx *sql.Tx, entityID int, after interface{}) error {
+		changes, err := diff(before, after)
+		if err != nil {
+			return err
+		}
+
+		actorType, actorID := actor.Type()
+		return s.repo.Create(tx, &models.AuditEntry{
+			ActorType:  actorType,
+			ActorID:    actorID,
+			ActorName:  actor.Name,
+			Action:     action,
+			EntityType: entityType,
+			EntityID:   entityID,
+			Changes:    changes,
+			RequestID:  actor.RequestID,
+		})
+	}
+}
+
+// diff returns the fields that differ between the JSON forms of before
+// and after
+func diff(before, after interface{}) (map[string]models.FieldChange, error) {
+	old, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.FieldChange{}
+	for field, value := range old {
+		next, ok := cur[field]
+		if !ok {
+			changes[field] = models.FieldChange{Before: value}
+		} else if string(next) != string(value) {
+			changes[field] = models.FieldChange{Before: value, After: next}
+		}
+	}
+	for field, value := range cur {
+		if _, ok := old[field]; !ok {
+			changes[field] = models.FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return fields, nil
+	}
+	return fields, json.Unmarshal(data, &fields)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+)
+
+func TestAuditedChangeRollsBackWithoutEntry(t *testing.T) {
+	db, calendar := newTestStore(t)
+	audit := NewAuditService(repositories.NewAuditRepository(db, calendar), calendar)
+	categories := NewCategoryService(repositories.NewCategoryRepository(db), audit)
+	actor := models.Actor{Name: "test"}
+
+	saved := &models.Category{Name: "Minuman"}
+	if err := categories.Create(saved, actor); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := audit.GetAll(models.AuditFilter{EntityType: models.EntityCategory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].EntityID != saved.ID {
+		t.Fatalf("got %d entries, want the create of category %d", len(entries), saved.ID)
+	}
+
+	// without an audit log the change must not be saved either
+	if _, err := db.Exec("ALTER TABLE audit_log RENAME TO audit_log_gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := categories.Create(&models.Category{Name: "Makanan"}, actor); err == nil {
+		t.Error("create succeeded without its audit entry")
+	}
+	saved.Name = "Minuman Dingin"
+	if err := categories.Update(saved, actor); err == nil {
+		t.Error("update succeeded without its audit entry")
+	}
+	if err := categories.Delete(saved.ID, actor); err == nil {
+		t.Error("delete succeeded without its audit entry")
+	}
+
+	all, err := categories.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Name != "Minuman" {
+		t.Errorf("categories = %+v, want only the unchanged Minuman", all)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/auth"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// AuthService signs users in and out and checks access tokens
+type AuthService struct {
+	users      *repositories.UserRepository
+	signer     *auth.Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewAuthService creates a new instance of AuthService. Access tokens live
+// for accessTTL; a session ends after refreshTTL without a refresh.
+func NewAuthService(
+	users *repositories.UserRepository,
+	signer *auth.Signer,
+	accessTTL, refreshTTL time.Duration,
+) *AuthService {
+	return &AuthService{users: users, signer: signer, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+var errBadCredentials = apperror.Unauthorized("auth.bad_credentials")
+
+// A supervisor PIN is locked for PINLockout after MaxPINFailures wrong
+// entries in a row, so a short PIN cannot be guessed at the till
+const (
+	MaxPINFailures = 5
+	PINLockout     = 15 * time.Minute
+)
+
+// Login checks a username and password and starts a session
+func (s *AuthService) Login(req models.LoginRequest) (*models.TokenResponse, error) {
+	user, hash, err := s.users.GetCredentials(strings.TrimSpace(req.Username))
+	if err != nil {
+		auth.CheckNoPassword(req.Password)
+		return nil, errBadCredentials
+	}
+	if !auth.CheckPassword(hash, req.Password) || !user.Active {
+		return nil, errBadCredentials
+	}
+
+	refreshToken, err := auth.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Truncate(time.Second).Add(s.refreshTTL)
+	session, err := s.users.CreateSession(user.ID, auth.HashToken(refreshToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(user, session, refreshToken)
+}
+
+// Refresh trades a refresh token for a new access and refresh token
+func (s *AuthService) Refresh(req models.RefreshRequest) (*models.TokenResponse, error) {
+	v := validate.New()
+	v.Required("refresh_token", req.RefreshToken)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Truncate(time.Second).Add(s.refreshTTL)
+	session, err := s.users.RotateSession(auth.HashToken(req.RefreshToken), auth.HashToken(refreshToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, session, refreshToken)
+}
+
+// Logout ends a session; its access tokens stop working straight away
+func (s *AuthService) Logout(sessionID int) error {
+	return s.users.RevokeSession(sessionID)
+}
+
+// Authenticate checks an access token and returns its user and session
+func (s *AuthService) Authenticate(token string) (*models.User, int, error) {
+	claims, err := s.signer.Verify(token, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	user, err := s.users.GetSessionUser(claims.SessionID, claims.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, claims.SessionID, nil
+}
+
+// Approve checks a supervisor override: the approver must be active,
+// have a PIN and hold permission themselves. A locked PIN is not checked.
+func (s *AuthService) Approve(username, pin, permission string) (*models.User, error) {
+	errDenied := apperror.Forbidden("auth.override_denied")
+	if !models.IsOverridable(permission) {
+		return nil, errDenied
+	}
+
+	approver, hash, err := s.users.GetApprover(strings.TrimSpace(username))
+	if err != nil || hash == "" {
+		auth.CheckNoPassword(pin)
+		return nil, errDenied
+	}
+
+	lockedUntil, err := s.users.PINLockedUntil(approver.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil != nil {
+		return nil, pinLocked(*lockedUntil)
+	}
+
+	if !auth.CheckPassword(hash, pin) {
+		lockedUntil, err := s.users.RecordPINFailure(approver.ID, MaxPINFailures, PINLockout)
+		if err != nil {
+			return nil, err
+		}
+		if lockedUntil != nil {
+			return nil, pinLocked(*lockedUntil)
+		}
+		return nil, errDenied
+	}
+	if err := s.users.ResetPINFailures(approver.ID); err != nil {
+		return nil, err
+	}
+	if !approver.Active || !models.HasPermission(approver.Role, permission) {
+		return nil, errDenied
+	}
+	return approver, nil
+}
+
+func pinLocked(until time.Time) *apperror.Error {
+	return apperror.New(apperror.CodeTooManyAttempts, "auth.pin_locked").
+		With("locked_until", until)
+}
+
+// Bootstrap creates the first user, an owner, when there are none yet, so
+// a fresh install can be signed in to. It reports whether a user was
+// created.
+func (s *AuthService) Bootstrap(username, password string) (bool, error) {
+	count, err := s.users.Count()
+	if err != nil || count > 0 || username == "" || password == "" {
+		return false, err
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	user := &models.User{Username: username, Name: username, Role: models.RoleOwner, Active: true}
+	if err := s.users.Create(user, hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *AuthService) issue(user *models.User, session *models.Session, refreshToken string) (*models.TokenResponse, error) {
+	now := time.Now().UTC()
+	accessToken, err := s.signer.Sign(auth.Claims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: session.ID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.accessTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+		User:             *user,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// DefaultCartTTLMinutes is how long a parked cart lives without changes
+// when it is created without a ttl_minutes
+const DefaultCartTTLMinutes = 120
+
+// CartService manages parked carts
+type CartService struct {
+	repo  *repositories.CartRepository
+	audit *AuditService
+}
+
+// NewCartService creates a new instance of CartService
+func NewCartService(repo *repositories.CartRepository, audit *AuditService) *CartService {
+	return &CartService{repo: repo, audit: audit}
+}
+
+// GetOpen lists the parked carts
+func (s *CartService) GetOpen() ([]models.Cart, error) {
+	return s.repo.GetOpen()
+}
+
+// Create parks a new cart, optionally with its first lines
+func (s *CartService) Create(req models.CartRequest) (*models.Cart, error) {
+	if req.TTLMinutes == 0 {
+		req.TTLMinutes = DefaultCartTTLMinutes
+	}
+
+	v := validate.New()
+	v.NotNegative("ttl_minutes", req.TTLMinutes)
+	for i, item := range req.Items {
+		validateCartItem(v, fmt.Sprintf("items[%d].", i), item.ProductID, item.Quantity)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(req)
+}
+
+// GetByID returns a cart with its lines
+func (s *CartService) GetByID(id int) (*models.Cart, error) {
+	return s.repo.GetByID(id)
+}
+
+// Update changes the label, customer, reservation and time to live of a cart
+func (s *CartService) Update(cart *models.Cart) error {
+	v := validate.New()
+	v.Positive("ttl_minutes", cart.TTLMinutes)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	return s.repo.Update(cart)
+}
+
+// AddItem adds quantity of a product to a cart
+func (s *CartService) AddItem(cartID int, item models.CheckoutItem) (*models.Cart, error) {
+	v := validate.New()
+	validateCartItem(v, "", item.ProductID, item.Quantity)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetItem(cartID, item.ProductID, item.Quantity, true); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+// SetItem replaces the quantity of a product in a cart
+func (s *CartService) SetItem(cartID, productID, quantity int) (*models.Cart, error) {
+	v := validate.New()
+	v.Positive("quantity", quantity)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetItem(cartID, productID, quantity, false); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+// RemoveItem takes a product out of a cart
+func (s *CartService) RemoveItem(cartID, productID int) (*models.Cart, error) {
+	if err := s.repo.RemoveItem(cartID, productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+// Cancel closes a cart without a sale
+func (s *CartService) Cancel(id int) error {
+	return s.repo.Cancel(id)
+}
+
+// Checkout turns a cart into a transaction, audited like a sale rung up
+// at the till
+func (s *CartService) Checkout(id int, req models.CartCheckoutRequest, actor models.Actor) (*models.Transaction, error) {
+	checkout := models.CheckoutRequest{
+		PaymentMethod: req.PaymentMethod,
+		Discount:      req.Discount,
+		CustomerID:    req.CustomerID,
+		RedeemPoints:  req.RedeemPoints,
+		VoucherCode:   req.VoucherCode,
+		UserID:        req.UserID,
+		ApprovedBy:    req.ApprovedBy,
+	}
+	v := validate.New()
+	prepareCheckout(v, &checkout)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Checkout(id, checkout, s.audit.Record(actor, models.AuditCreate, models.EntityTransaction, nil))
+}
+
+// ExpireAll closes the carts past their expiry
+func (s *CartService) ExpireAll() (int, error) {
+	return s.repo.ExpireAll()
+}
+
+// validateCartItem checks a cart line; prefix is the JSON path of the line
+func validateCartItem(v *validate.Validator, prefix string, productID, quantity int) {
+	v.Check(productID > 0, prefix+"product_id", validate.Required, "field.required", prefix+"product_id")
+	v.Positive(prefix+"quantity", quantity)
+}
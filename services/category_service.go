@@ -1,18 +1,21 @@
 package services
 
 import (
+	"strings"
 	"task-crud-kategori/models"
 	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
 )
 
 // CategoryService provides category-related business logic
 type CategoryService struct {
-	repo *repositories.CategoryRepository
+	repo  *repositories.CategoryRepository
+	audit *AuditService
 }
 
 // NewCategoryService creates a new instance of CategoryService
-func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService {
-	return &CategoryService{repo: repo}
+func NewCategoryService(repo *repositories.CategoryRepository, audit *AuditService) *CategoryService {
+	return &CategoryService{repo: repo, audit: audit}
 }
 
 // =======================
@@ -25,8 +28,11 @@ func (s *CategoryService) GetAll() ([]models.Category, error) {
 }
 
 // Create adds a new category
-func (s *CategoryService) Create(data *models.Category) error {
-	return s.repo.Create(data)
+func (s *CategoryService) Create(data *models.Category, actor models.Actor) error {
+	if err := validateCategory(data); err != nil {
+		return err
+	}
+	return s.repo.Create(data, s.audit.Record(actor, models.AuditCreate, models.EntityCategory, nil))
 }
 
 // GetByID retrieves a category by its ID
@@ -35,11 +41,33 @@ func (s *CategoryService) GetByID(id int) (*models.Category, error) {
 }
 
 // Update modifies an existing category
-func (s *CategoryService) Update(category *models.Category) error {
-	return s.repo.Update(category)
+func (s *CategoryService) Update(category *models.Category, actor models.Actor) error {
+	before, err := s.repo.GetByID(category.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+	return s.repo.Update(category, s.audit.Record(actor, models.AuditUpdate, models.EntityCategory, before))
 }
 
 // Delete removes a category by its ID
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Delete(id int, actor models.Actor) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(id, s.audit.Record(actor, models.AuditDelete, models.EntityCategory, before))
+}
+
+func validateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Description = strings.TrimSpace(category.Description)
+
+	v := validate.New()
+	v.Required("name", category.Name)
+	return v.Err()
 }
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"sort"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// CreditService handles customer credit (kasbon) and receivables
+type CreditService struct {
+	repo     *repositories.CreditRepository
+	calendar *clock.Calendar
+}
+
+// NewCreditService creates a new instance of CreditService
+func NewCreditService(repo *repositories.CreditRepository, calendar *clock.Calendar) *CreditService {
+	return &CreditService{repo: repo, calendar: calendar}
+}
+
+// GetAccount returns a customer's credit balance and open credit sales
+func (s *CreditService) GetAccount(customerID int) (*models.CreditAccount, error) {
+	return s.repo.GetAccount(customerID)
+}
+
+// Repay books a repayment towards a customer's balance
+func (s *CreditService) Repay(customerID int, req models.RepaymentRequest) (*models.Repayment, error) {
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = models.PaymentCash
+	}
+
+	v := validate.New()
+	v.Positive("amount", req.Amount)
+	v.Check(models.IsValidPaymentMethod(req.PaymentMethod) && req.PaymentMethod != models.PaymentCredit,
+		"payment_method", validate.Invalid, "checkout.invalid_payment_method")
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Repay(customerID, req)
+}
+
+// GetAging splits what every customer owes by the age of the credit
+// sales: 0-30, 31-60 and over 60 days. Largest balances come first.
+func (s *CreditService) GetAging() (*models.AgingReport, error) {
+	today := s.calendar.Today()
+
+	invoices, err := s.repo.GetOpenInvoices(0, today)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := s.repo.GetCustomers()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AgingReport{
+		AsOf:      today.Format(clock.DateLayout),
+		Customers: []models.CustomerAging{},
+	}
+
+	for customerID, open := range invoices {
+		aging := customers[customerID]
+		aging.CustomerID = customerID
+
+		for _, inv := range open {
+			addToBucket(&aging.AgingBuckets, inv.AgeDays, inv.Outstanding)
+			addToBucket(&report.Totals, inv.AgeDays, inv.Outstanding)
+			aging.OldestDays = max(aging.OldestDays, inv.AgeDays)
+		}
+		report.Customers = append(report.Customers, aging)
+	}
+
+	sort.Slice(report.Customers, func(i, j int) bool {
+		a, b := report.Customers[i], report.Customers[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.CustomerID < b.CustomerID
+	})
+
+	return report, nil
+}
+
+func addToBucket(b *models.AgingBuckets, ageDays, amount int) {
+	switch {
+	case ageDays <= 30:
+		b.Days0To30 += amount
+	case ageDays <= 60:
+		b.Days31To60 += amount
+	default:
+		b.Over60 += amount
+	}
+	b.Total += amount
+}
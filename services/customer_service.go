@@ -0,0 +1,135 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// CustomerService provides customer records, purchase history and
+// lifetime value
+type CustomerService struct {
+	repo            *repositories.CustomerRepository
+	transactionRepo *repositories.TransactionRepository
+	calendar        *clock.Calendar
+}
+
+// NewCustomerService creates a new instance of CustomerService
+func NewCustomerService(
+	repo *repositories.CustomerRepository,
+	transactionRepo *repositories.TransactionRepository,
+	calendar *clock.Calendar,
+) *CustomerService {
+	return &CustomerService{repo: repo, transactionRepo: transactionRepo, calendar: calendar}
+}
+
+// GetAll lists customers, optionally matching search
+func (s *CustomerService) GetAll(search string) ([]models.Customer, error) {
+	return s.repo.GetAll(strings.TrimSpace(search))
+}
+
+// Create adds a new customer
+func (s *CustomerService) Create(customer *models.Customer) error {
+	if err := validateCustomer(customer); err != nil {
+		return err
+	}
+	return s.repo.Create(customer)
+}
+
+// GetByID retrieves a customer by its ID
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+// Update modifies an existing customer
+func (s *CustomerService) Update(customer *models.Customer) error {
+	if err := validateCustomer(customer); err != nil {
+		return err
+	}
+	return s.repo.Update(customer)
+}
+
+// Delete removes a customer without purchases
+func (s *CustomerService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// GetHistory returns a customer with all their transactions
+func (s *CustomerService) GetHistory(id int) (*models.CustomerHistory, error) {
+	customer, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionRepo.GetByCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CustomerHistory{Customer: *customer, Transactions: transactions}, nil
+}
+
+// GetLifetimeValue returns what a customer has spent so far
+func (s *CustomerService) GetLifetimeValue(id int) (*models.CustomerValue, error) {
+	values, err := s.repo.GetValues(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, apperror.NotFound("customer.not_found")
+	}
+
+	s.complete(&values[0])
+	return &values[0], nil
+}
+
+// GetTopCustomers ranks customers by lifetime value
+func (s *CustomerService) GetTopCustomers(limit int) ([]models.CustomerValue, error) {
+	if limit < 1 {
+		return nil, apperror.Validation("field.min", "limit", 1)
+	}
+
+	values, err := s.repo.GetValues(0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range values {
+		s.complete(&values[i])
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].LifetimeValue > values[j].LifetimeValue
+	})
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return values, nil
+}
+
+// complete fills in the figures derived from the sums
+func (s *CustomerService) complete(v *models.CustomerValue) {
+	v.LifetimeValue = v.GrossSpend - v.Refunds
+	v.AverageBasket = averageBasket(v.LifetimeValue, v.Transactions-v.RefundedTransactions)
+
+	if v.LastPurchaseAt != nil {
+		days := clock.DaysBetween(s.calendar.DateOf(*v.LastPurchaseAt), s.calendar.Today())
+		v.DaysSinceLastPurchase = &days
+	}
+}
+
+func validateCustomer(customer *models.Customer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Phone = strings.TrimSpace(customer.Phone)
+	customer.Email = strings.TrimSpace(customer.Email)
+
+	v := validate.New()
+	v.Required("name", customer.Name)
+	v.Check(customer.Email == "" || strings.Contains(customer.Email, "@"), "email", validate.Invalid, "field.email", "email")
+	v.NotNegative("credit_limit", customer.CreditLimit)
+	return v.Err()
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+	"time"
+)
+
+// EmployeeService manages staff profiles and the time clock
+type EmployeeService struct {
+	repo     *repositories.EmployeeRepository
+	calendar *clock.Calendar
+}
+
+// NewEmployeeService creates a new instance of EmployeeService
+func NewEmployeeService(repo *repositories.EmployeeRepository, calendar *clock.Calendar) *EmployeeService {
+	return &EmployeeService{repo: repo, calendar: calendar}
+}
+
+// Today returns the current business date of the store
+func (s *EmployeeService) Today() time.Time {
+	return s.calendar.Today()
+}
+
+// ParseDate parses a YYYY-MM-DD business date in the store timezone
+func (s *EmployeeService) ParseDate(value string) (time.Time, error) {
+	return s.calendar.ParseDate(value)
+}
+
+// GetAll lists the employees, inactive ones only when asked for
+func (s *EmployeeService) GetAll(includeInactive bool) ([]models.Employee, error) {
+	return s.repo.GetAll(includeInactive)
+}
+
+// GetByID retrieves an employee by their ID
+func (s *EmployeeService) GetByID(id int) (*models.Employee, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetByUserID retrieves the employee linked to a user account
+func (s *EmployeeService) GetByUserID(userID int) (*models.Employee, error) {
+	return s.repo.GetByUserID(userID)
+}
+
+// Create adds an employee, active unless the request says otherwise
+func (s *EmployeeService) Create(req models.EmployeeRequest) (*models.Employee, error) {
+	employee := &models.Employee{
+		UserID:   req.UserID,
+		Name:     strings.TrimSpace(req.Name),
+		Phone:    strings.TrimSpace(req.Phone),
+		Position: strings.TrimSpace(req.Position),
+		HiredOn:  strings.TrimSpace(req.HiredOn),
+		Active:   true,
+	}
+	if req.Active != nil {
+		employee.Active = *req.Active
+	}
+	if err := validateEmployee(employee); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(employee); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// Update changes an employee; empty fields keep their value
+func (s *EmployeeService) Update(id int, req models.EmployeeRequest) (*models.Employee, error) {
+	employee, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UnlinkUser {
+		employee.UserID = nil
+	} else if req.UserID != nil {
+		employee.UserID = req.UserID
+	}
+	if req.Name != "" {
+		employee.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Phone != "" {
+		employee.Phone = strings.TrimSpace(req.Phone)
+	}
+	if req.Position != "" {
+		employee.Position = strings.TrimSpace(req.Position)
+	}
+	if req.HiredOn != "" {
+		employee.HiredOn = strings.TrimSpace(req.HiredOn)
+	}
+	if req.Active != nil {
+		if !*req.Active && employee.ClockedIn != nil {
+			return nil, apperror.Conflict("employee.clock_out_first")
+		}
+		employee.Active = *req.Active
+	}
+	if err := validateEmployee(employee); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(employee); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// Delete removes an employee who never clocked in
+func (s *EmployeeService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// ClockIn starts a time entry for an employee
+func (s *EmployeeService) ClockIn(id int, req models.ClockRequest) (*models.TimeEntry, error) {
+	return s.repo.ClockIn(id, strings.TrimSpace(req.Note))
+}
+
+// ClockOut ends the open time entry of an employee
+func (s *EmployeeService) ClockOut(id int, req models.ClockRequest) (*models.TimeEntry, error) {
+	return s.repo.ClockOut(id, strings.TrimSpace(req.Note))
+}
+
+// GetTimesheet returns the time entries of an employee over the business
+// days from start to end, with the minutes worked
+func (s *EmployeeService) GetTimesheet(id int, start, end time.Time) (*models.Timesheet, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+
+	employee, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetTimeEntries(id, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &models.Timesheet{
+		EmployeeID:   employee.ID,
+		EmployeeName: employee.Name,
+		StartDate:    start.Format(clock.DateLayout),
+		EndDate:      end.Format(clock.DateLayout),
+		Entries:      entries,
+	}
+	for _, e := range entries {
+		sheet.TotalMinutes += e.Minutes
+	}
+	return sheet, nil
+}
+
+func validateEmployee(e *models.Employee) error {
+	v := validate.New()
+	v.Required("name", e.Name)
+	v.Date("hired_on", e.HiredOn)
+	return v.Err()
+}
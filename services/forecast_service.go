@@ -0,0 +1,203 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"task-crud-kategori/analytics"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"time"
+)
+
+// MaxForecastHistory caps the business days of sales history a forecast or
+// backtest reads, two years
+const MaxForecastHistory = 730
+
+// ForecastService projects product demand from the sales history
+type ForecastService struct {
+	repo     *repositories.ReportRepository
+	calendar *clock.Calendar
+}
+
+// NewForecastService creates a new instance of ForecastService
+func NewForecastService(repo *repositories.ReportRepository, calendar *clock.Calendar) *ForecastService {
+	return &ForecastService{repo: repo, calendar: calendar}
+}
+
+// IsValidForecastMethod reports whether method is a known forecast method
+func IsValidForecastMethod(method string) bool {
+	_, ok := analytics.Methods[method]
+	return ok
+}
+
+// =======================
+// FORECAST
+// =======================
+// Forecast projects the demand of the next days business days, starting
+// today, from the historyDays complete business days before today. With
+// productID 0 every product is forecast, the ones running out first on top.
+func (s *ForecastService) Forecast(productID, days, historyDays int, method string) (*models.ForecastReport, error) {
+	if days < 1 || days > 365 {
+		return nil, apperror.Validation("field.out_of_range", "days", 1, 365)
+	}
+	if historyDays < analytics.SeasonLength {
+		return nil, apperror.Validation("field.min_days", "history", 7)
+	}
+	if historyDays > MaxForecastHistory {
+		return nil, apperror.Validation("field.out_of_range", "history", analytics.SeasonLength, MaxForecastHistory)
+	}
+	if !IsValidForecastMethod(method) {
+		return nil, apperror.Validation("field.one_of", "method", "moving_average, seasonal")
+	}
+
+	today := s.calendar.Today()
+	end := today.AddDate(0, 0, -1)
+	start := end.AddDate(0, 0, -(historyDays - 1))
+
+	history, err := s.repo.GetDemandHistory(start, end, productID)
+	if err != nil {
+		return nil, err
+	}
+	if productID != 0 && len(history) == 0 {
+		return nil, apperror.NotFound("product.not_found")
+	}
+
+	report := &models.ForecastReport{
+		Method:       method,
+		HistoryStart: start.Format(clock.DateLayout),
+		HistoryEnd:   end.Format(clock.DateLayout),
+		Days:         days,
+		Products:     make([]models.ProductForecast, 0, len(history)),
+	}
+
+	for _, h := range history {
+		series := s.series(h, start, end)
+		projected := analytics.Methods[method](series, days)
+
+		forecast := models.ProductForecast{
+			ProductID:   h.ProductID,
+			Name:        h.Name,
+			Stock:       h.Stock,
+			HistoryDays: len(series),
+			Forecast:    make([]models.ForecastPoint, days),
+		}
+
+		total := 0.0
+		for _, q := range series {
+			total += q
+		}
+		if len(series) > 0 {
+			forecast.AverageDaily = round2(total / float64(len(series)))
+		}
+
+		for i, q := range projected {
+			forecast.Forecast[i] = models.ForecastPoint{
+				Date:     today.AddDate(0, 0, i).Format(clock.DateLayout),
+				Quantity: round2(q),
+			}
+			forecast.ForecastTotal += q
+		}
+		forecast.ForecastTotal = round2(forecast.ForecastTotal)
+
+		if remaining, ok := analytics.DaysOfStock(float64(h.Stock), projected); ok {
+			remaining = round2(remaining)
+			stockout := today.AddDate(0, 0, int(math.Floor(remaining))).Format(clock.DateLayout)
+			forecast.DaysOfStock = &remaining
+			forecast.StockoutDate = &stockout
+		}
+
+		report.Products = append(report.Products, forecast)
+	}
+
+	// soonest stock-out first, products without demand last
+	sort.SliceStable(report.Products, func(i, j int) bool {
+		a, b := report.Products[i].DaysOfStock, report.Products[j].DaysOfStock
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a < *b
+	})
+
+	return report, nil
+}
+
+// =======================
+// BACKTEST
+// =======================
+// Backtest forecasts the last holdout days of the history from the days
+// before them with every method and compares against what was sold.
+// Products without sales in the history are left out.
+func (s *ForecastService) Backtest(historyDays, holdout int) (*models.BacktestReport, error) {
+	if holdout < 1 {
+		return nil, apperror.Validation("field.min", "holdout", 1)
+	}
+	if historyDays < holdout+analytics.SeasonLength {
+		return nil, apperror.Validation("forecast.history_short")
+	}
+	if historyDays > MaxForecastHistory {
+		return nil, apperror.Validation("field.out_of_range", "history", holdout+analytics.SeasonLength, MaxForecastHistory)
+	}
+
+	end := s.calendar.Today().AddDate(0, 0, -1)
+	start := end.AddDate(0, 0, -(historyDays - 1))
+
+	history, err := s.repo.GetDemandHistory(start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	series := [][]float64{}
+	for _, h := range history {
+		if len(h.Daily) > 0 {
+			series = append(series, s.series(h, start, end))
+		}
+	}
+
+	report := &models.BacktestReport{
+		HistoryStart: start.Format(clock.DateLayout),
+		HistoryEnd:   end.Format(clock.DateLayout),
+		Holdout:      holdout,
+		Results:      []models.BacktestResult{},
+	}
+
+	bestWAPE := math.Inf(1)
+	for _, name := range analytics.MethodNames {
+		acc := analytics.Backtest(series, holdout, analytics.Methods[name])
+		report.Results = append(report.Results, models.BacktestResult{
+			Method:   name,
+			Products: acc.Series,
+			Points:   acc.Points,
+			MAE:      round4(acc.MAE),
+			RMSE:     round4(acc.RMSE),
+			WAPE:     round4(acc.WAPE),
+			Bias:     round4(acc.Bias),
+		})
+
+		if acc.Points > 0 && acc.WAPE < bestWAPE {
+			bestWAPE = acc.WAPE
+			report.Best = name
+		}
+	}
+
+	return report, nil
+}
+
+// series lays the daily sales of a product out from start to end, one
+// value per business day. Days before the product existed are cut off so
+// they do not pass for days without demand.
+func (s *ForecastService) series(h models.DemandHistory, start, end time.Time) []float64 {
+	if h.CreatedAt != nil {
+		// both are midnight in the store timezone
+		if created := s.calendar.DateOf(*h.CreatedAt); created.After(start) {
+			start = created
+		}
+	}
+
+	series := []float64{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		series = append(series, float64(h.Daily[day.Format(clock.DateLayout)]))
+	}
+	return series
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// LoyaltyService manages the loyalty program and customers' points
+type LoyaltyService struct {
+	repo *repositories.LoyaltyRepository
+}
+
+// NewLoyaltyService creates a new instance of LoyaltyService
+func NewLoyaltyService(repo *repositories.LoyaltyRepository) *LoyaltyService {
+	return &LoyaltyService{repo: repo}
+}
+
+// GetProgram returns the settings, earning rules and tiers
+func (s *LoyaltyService) GetProgram() (*models.LoyaltyProgram, error) {
+	return s.repo.GetProgram()
+}
+
+// UpdateProgram replaces the whole program configuration
+func (s *LoyaltyService) UpdateProgram(program *models.LoyaltyProgram) error {
+	v := validate.New()
+	v.NotNegative("point_value", program.PointValue)
+	v.Check(program.ExpiryDays >= 1, "expiry_days", validate.OutOfRange, "field.min", "expiry_days", 1)
+
+	categories := map[int]bool{}
+	for i, rule := range program.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		v.NotNegative(field+".category_id", rule.CategoryID)
+		v.NotNegative(field+".rupiah_per_point", rule.RupiahPerPoint)
+		v.Check(!categories[rule.CategoryID], field+".category_id", validate.Duplicate, "loyalty.duplicate_rule")
+		categories[rule.CategoryID] = true
+	}
+
+	names := map[string]bool{}
+	for i := range program.Tiers {
+		tier := &program.Tiers[i]
+		field := fmt.Sprintf("tiers[%d]", i)
+		tier.Name = strings.ToLower(strings.TrimSpace(tier.Name))
+		v.Required(field+".name", tier.Name)
+		v.Check(tier.Name == "" || !names[tier.Name], field+".name", validate.Duplicate, "loyalty.duplicate_tier")
+		v.NotNegative(field+".min_points", tier.MinPoints)
+		v.Range(field+".discount_percent", tier.DiscountPercent, 0, 100)
+		names[tier.Name] = true
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	if program.Rules == nil {
+		program.Rules = []models.LoyaltyRule{}
+	}
+	if program.Tiers == nil {
+		program.Tiers = []models.LoyaltyTier{}
+	}
+	return s.repo.UpdateProgram(program)
+}
+
+// GetAccount returns a customer's balance and tier
+func (s *LoyaltyService) GetAccount(customerID int) (*models.LoyaltyAccount, error) {
+	return s.repo.GetAccount(customerID)
+}
+
+// GetLedger lists a customer's points movements
+func (s *LoyaltyService) GetLedger(customerID int) ([]models.LoyaltyEntry, error) {
+	return s.repo.GetLedger(customerID)
+}
+
+// ExpireAll writes off all points past their expiry date
+func (s *LoyaltyService) ExpireAll() (*models.ExpireResult, error) {
+	return s.repo.ExpireAll()
+}
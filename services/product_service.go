@@ -1,34 +1,94 @@
 package services
 
 import (
+	"strings"
 	"task-crud-kategori/models"
 	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
 )
 
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo         *repositories.ProductRepository
+	movementRepo *repositories.StockMovementRepository
+	audit        *AuditService
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(
+	repo *repositories.ProductRepository,
+	movementRepo *repositories.StockMovementRepository,
+	audit *AuditService,
+) *ProductService {
+	return &ProductService{repo: repo, movementRepo: movementRepo, audit: audit}
 }
 
 func (s *ProductService) GetAll(name string) ([]models.Product, error) {
 	return s.repo.GetAll(name)
 }
 
-func (s *ProductService) Create(data *models.Product) error {
-	return s.repo.Create(data)
+// GetByCategory lists the products of a category
+func (s *ProductService) GetByCategory(categoryID int) ([]models.Product, error) {
+	return s.repo.GetByCategory(categoryID)
+}
+
+func (s *ProductService) Create(data *models.Product, actor models.Actor) error {
+	if err := s.validate(data); err != nil {
+		return err
+	}
+	return s.repo.Create(data, s.audit.Record(actor, models.AuditCreate, models.EntityProduct, nil))
 }
 
 func (s *ProductService) GetByID(id int) (*models.Product, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *ProductService) Update(product *models.Product) error {
-	return s.repo.Update(product)
+func (s *ProductService) Update(product *models.Product, actor models.Actor) error {
+	before, err := s.repo.GetByID(product.ID)
+	if err != nil {
+		return err
+	}
+	before.Category = nil
+
+	if err := s.validate(product); err != nil {
+		return err
+	}
+	return s.repo.Update(product, s.audit.Record(actor, models.AuditUpdate, models.EntityProduct, before))
+}
+
+func (s *ProductService) Delete(id int, actor models.Actor) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	before.Category = nil
+
+	return s.repo.Delete(id, s.audit.Record(actor, models.AuditDelete, models.EntityProduct, before))
+}
+
+// validate checks every field of a product before it is written
+func (s *ProductService) validate(product *models.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+
+	v := validate.New()
+	v.Required("name", product.Name)
+	v.NotNegative("price", product.Price)
+	v.NotNegative("cost", product.Cost)
+	v.NotNegative("stock", product.Stock)
+	if product.CategoryID <= 0 {
+		v.Add("category_id", validate.Required, "field.required", "category_id")
+	} else {
+		exists, err := s.repo.CategoryExists(product.CategoryID)
+		if err != nil {
+			return err
+		}
+		v.Check(exists, "category_id", validate.Unknown, "product.unknown_category")
+	}
+	return v.Err()
 }
 
-func (s *ProductService) Delete(id int) error {
-	return s.repo.Delete(id)
+// GetMovements lists the stock movements of a product, newest first
+func (s *ProductService) GetMovements(id int) ([]models.StockMovement, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.movementRepo.GetByProduct(id)
 }
@@ -1,15 +1,809 @@
 package services
 
-import "task-crud-kategori/repositories"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"task-crud-kategori/analytics"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"time"
+)
+
+// Time-series bucket sizes
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// maxBuckets caps the number of buckets a time series may have per interval
+var maxBuckets = map[string]int{
+	IntervalDay:   366,
+	IntervalWeek:  260,
+	IntervalMonth: 120,
+}
 
 type ReportService struct {
-	repo *repositories.ReportRepository
+	repo     *repositories.ReportRepository
+	calendar *clock.Calendar
+}
+
+func NewReportService(repo *repositories.ReportRepository, calendar *clock.Calendar) *ReportService {
+	return &ReportService{repo: repo, calendar: calendar}
+}
+
+// Today returns the current business date of the store
+func (s *ReportService) Today() time.Time {
+	return s.calendar.Today()
+}
+
+// ParseDate parses a YYYY-MM-DD business date in the store timezone
+func (s *ReportService) ParseDate(value string) (time.Time, error) {
+	return s.calendar.ParseDate(value)
+}
+
+// Product ranking keys
+const (
+	RankByQuantity = "quantity"
+	RankByRevenue  = "revenue"
+)
+
+// GetSummary returns the totals of the business days from startDate to
+// endDate plus the breakdowns selected in opts. Zero dates mean today.
+func (s *ReportService) GetSummary(startDate, endDate time.Time, opts models.ReportOptions) (*models.ReportSummary, error) {
+	if startDate.IsZero() || endDate.IsZero() {
+		startDate = s.calendar.Today()
+		endDate = startDate
+	}
+	if endDate.Before(startDate) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+
+	summary, err := s.repo.GetSummary(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Top <= 0 && opts.Bottom <= 0 && !opts.ByCategory {
+		return summary, nil
+	}
+
+	products, err := s.repo.GetProductSales(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	totalQty, totalRevenue := 0, 0
+	for _, p := range products {
+		totalQty += p.Quantity
+		totalRevenue += p.Revenue
+	}
+	for i := range products {
+		products[i].QuantityShare = share(products[i].Quantity, totalQty)
+		products[i].RevenueShare = share(products[i].Revenue, totalRevenue)
+	}
+
+	if opts.Top > 0 || opts.Bottom > 0 {
+		rankProducts(products, opts.RankBy)
+
+		if opts.Top > 0 {
+			// only products that actually sold can be top sellers
+			sold := []models.ProductSales{}
+			for _, p := range products {
+				if p.Quantity > 0 {
+					sold = append(sold, p)
+				}
+			}
+			summary.TopProducts = sold[:min(opts.Top, len(sold))]
+		}
+
+		if opts.Bottom > 0 {
+			bottom := []models.ProductSales{}
+			for i := len(products) - 1; i >= 0 && len(bottom) < opts.Bottom; i-- {
+				bottom = append(bottom, products[i])
+			}
+			summary.BottomProducts = bottom
+		}
+	}
+
+	if opts.ByCategory {
+		summary.Categories = groupByCategory(products, totalQty, totalRevenue)
+		sort.SliceStable(summary.Categories, func(i, j int) bool {
+			return rankValue(summary.Categories[i].Quantity, summary.Categories[i].Revenue, opts.RankBy) >
+				rankValue(summary.Categories[j].Quantity, summary.Categories[j].Revenue, opts.RankBy)
+		})
+	}
+
+	return summary, nil
 }
 
-func NewReportService(repo *repositories.ReportRepository) *ReportService {
-	return &ReportService{repo: repo}
+// IsValidRankBy reports whether rankBy is a supported ranking key
+func IsValidRankBy(rankBy string) bool {
+	return rankBy == RankByQuantity || rankBy == RankByRevenue
+}
+
+// rankProducts sorts products best first, ties broken by the other measure
+func rankProducts(products []models.ProductSales, rankBy string) {
+	sort.SliceStable(products, func(i, j int) bool {
+		a := rankValue(products[i].Quantity, products[i].Revenue, rankBy)
+		b := rankValue(products[j].Quantity, products[j].Revenue, rankBy)
+		if a != b {
+			return a > b
+		}
+		return rankValue(products[i].Quantity, products[i].Revenue, otherRankBy(rankBy)) >
+			rankValue(products[j].Quantity, products[j].Revenue, otherRankBy(rankBy))
+	})
+}
+
+func rankValue(quantity, revenue int, rankBy string) int {
+	if rankBy == RankByRevenue {
+		return revenue
+	}
+	return quantity
+}
+
+func otherRankBy(rankBy string) string {
+	if rankBy == RankByRevenue {
+		return RankByQuantity
+	}
+	return RankByRevenue
+}
+
+// groupByCategory sums product sales per category; products without a
+// category are grouped under category 0
+func groupByCategory(products []models.ProductSales, totalQty, totalRevenue int) []models.CategorySales {
+	index := map[int]int{}
+	categories := []models.CategorySales{}
+
+	for _, p := range products {
+		i, ok := index[p.CategoryID]
+		if !ok {
+			name := p.CategoryName
+			if name == "" {
+				name = "Tanpa kategori"
+			}
+			categories = append(categories, models.CategorySales{CategoryID: p.CategoryID, Name: name})
+			i = len(categories) - 1
+			index[p.CategoryID] = i
+		}
+
+		if p.Quantity > 0 {
+			categories[i].Products++
+		}
+		categories[i].Quantity += p.Quantity
+		categories[i].Revenue += p.Revenue
+	}
+
+	for i := range categories {
+		categories[i].QuantityShare = share(categories[i].Quantity, totalQty)
+		categories[i].RevenueShare = share(categories[i].Revenue, totalRevenue)
+	}
+
+	return categories
+}
+
+// share returns part as a percentage of total
+func share(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round2(float64(part) / float64(total) * 100)
+}
+
+// IsValidInterval reports whether interval is a supported bucket size
+func IsValidInterval(interval string) bool {
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	}
+	return false
+}
+
+// GetTimeSeries buckets sales between start and end (inclusive) by interval.
+// When compare is true the previous period of the same length is included
+// together with the growth of the totals.
+func (s *ReportService) GetTimeSeries(start, end time.Time, interval string, compare bool) (*models.TimeSeriesReport, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+	if !IsValidInterval(interval) {
+		return nil, apperror.Validation("field.one_of", "interval", "day, week, month")
+	}
+	if limit := maxBuckets[interval]; bucketCount(start, end, interval, limit) > limit {
+		return nil, apperror.Validation("report.too_many_buckets", interval, limit).
+			With("interval", interval).
+			With("max_buckets", limit)
+	}
+
+	current, err := s.buildPeriod(start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TimeSeriesReport{
+		Interval: interval,
+		Current:  *current,
+	}
+
+	if compare {
+		days := clock.DaysBetween(start, end) + 1
+		prevEnd := start.AddDate(0, 0, -1)
+		prevStart := prevEnd.AddDate(0, 0, -(days - 1))
+
+		previous, err := s.buildPeriod(prevStart, prevEnd, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Previous = previous
+		report.Growth = &models.SalesGrowth{
+			Revenue:       percentChange(float64(current.Totals.Revenue), float64(previous.Totals.Revenue)),
+			Transactions:  percentChange(float64(current.Totals.Transactions), float64(previous.Totals.Transactions)),
+			ItemsSold:     percentChange(float64(current.Totals.ItemsSold), float64(previous.Totals.ItemsSold)),
+			AverageBasket: percentChange(current.Totals.AverageBasket, previous.Totals.AverageBasket),
+		}
+	}
+
+	return report, nil
+}
+
+// bucketCount counts the buckets from start to end, stopping once it
+// passes limit
+func bucketCount(start, end time.Time, interval string, limit int) int {
+	count := 0
+	for b := start; !b.After(end) && count <= limit; b = nextBucket(b, interval) {
+		count++
+	}
+	return count
+}
+
+// buildPeriod loads daily sales and folds them into zero-filled buckets
+func (s *ReportService) buildPeriod(start, end time.Time, interval string) (*models.SalesPeriod, error) {
+	days, err := s.repo.GetDailySales(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]models.DailySales, len(days))
+	for _, d := range days {
+		byDate[d.Date] = d
+	}
+
+	period := &models.SalesPeriod{
+		StartDate: start.Format(clock.DateLayout),
+		EndDate:   end.Format(clock.DateLayout),
+		Buckets:   []models.SalesBucket{},
+		Totals: models.SalesBucket{
+			Period:    "total",
+			StartDate: start.Format(clock.DateLayout),
+			EndDate:   end.Format(clock.DateLayout),
+		},
+	}
+
+	for bucketStart := start; !bucketStart.After(end); {
+		next := nextBucket(bucketStart, interval)
+		bucketEnd := next.AddDate(0, 0, -1)
+		if bucketEnd.After(end) {
+			bucketEnd = end
+		}
+
+		bucket := models.SalesBucket{
+			Period:    bucketLabel(bucketStart, interval),
+			StartDate: bucketStart.Format(clock.DateLayout),
+			EndDate:   bucketEnd.Format(clock.DateLayout),
+		}
+		for d := bucketStart; !d.After(bucketEnd); d = d.AddDate(0, 0, 1) {
+			if day, ok := byDate[d.Format(clock.DateLayout)]; ok {
+				bucket.Revenue += day.Revenue
+				bucket.Transactions += day.Transactions
+				bucket.ItemsSold += day.ItemsSold
+			}
+		}
+		bucket.AverageBasket = averageBasket(bucket.Revenue, bucket.Transactions)
+
+		period.Buckets = append(period.Buckets, bucket)
+		period.Totals.Revenue += bucket.Revenue
+		period.Totals.Transactions += bucket.Transactions
+		period.Totals.ItemsSold += bucket.ItemsSold
+
+		bucketStart = next
+	}
+	period.Totals.AverageBasket = averageBasket(period.Totals.Revenue, period.Totals.Transactions)
+
+	return period, nil
+}
+
+// GetHeatmap aggregates net sales by weekday and hour-of-day, so its totals
+// match the summary of the period. peaks is the number of busiest cells (by
+// transactions) to list as peak hours.
+func (s *ReportService) GetHeatmap(start, end time.Time, peaks int) (*models.SalesHeatmap, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+
+	cells, err := s.repo.GetHourlySales(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &models.SalesHeatmap{
+		StartDate: start.Format(clock.DateLayout),
+		EndDate:   end.Format(clock.DateLayout),
+		Timezone:  s.calendar.Location.String(),
+		Cells:     cells,
+		ByHour:    make([]models.HourTotal, 24),
+		ByWeekday: make([]models.WeekdayTotal, 7),
+	}
+
+	for i := range heatmap.ByHour {
+		heatmap.ByHour[i].Hour = i
+	}
+	for i := range heatmap.ByWeekday {
+		heatmap.ByWeekday[i].Weekday = i + 1
+		heatmap.ByWeekday[i].DayName = cells[i*24].DayName
+	}
+
+	for i := range cells {
+		cells[i].AverageBasket = averageBasket(cells[i].Revenue, cells[i].Transactions)
+
+		hour := &heatmap.ByHour[cells[i].Hour]
+		hour.Revenue += cells[i].Revenue
+		hour.Transactions += cells[i].Transactions
+
+		day := &heatmap.ByWeekday[cells[i].Weekday-1]
+		day.Revenue += cells[i].Revenue
+		day.Transactions += cells[i].Transactions
+	}
+	for i := range heatmap.ByHour {
+		heatmap.ByHour[i].AverageBasket = averageBasket(heatmap.ByHour[i].Revenue, heatmap.ByHour[i].Transactions)
+	}
+	for i := range heatmap.ByWeekday {
+		heatmap.ByWeekday[i].AverageBasket = averageBasket(heatmap.ByWeekday[i].Revenue, heatmap.ByWeekday[i].Transactions)
+	}
+
+	// busiest cells first, empty cells are never peaks
+	busiest := []models.HeatmapCell{}
+	for _, c := range cells {
+		if c.Transactions > 0 {
+			busiest = append(busiest, c)
+		}
+	}
+	sort.SliceStable(busiest, func(i, j int) bool {
+		if busiest[i].Transactions != busiest[j].Transactions {
+			return busiest[i].Transactions > busiest[j].Transactions
+		}
+		return busiest[i].Revenue > busiest[j].Revenue
+	})
+	heatmap.PeakHours = busiest[:min(peaks, len(busiest))]
+
+	return heatmap, nil
+}
+
+// GetABC classifies products by their share of the net revenue or quantity
+// sold between start and end. thresholdA and thresholdB are cumulative
+// percentages, typically 80 and 95.
+func (s *ReportService) GetABC(start, end time.Time, by string, thresholdA, thresholdB float64) (*models.AbcReport, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+	if !IsValidRankBy(by) {
+		return nil, apperror.Validation("field.one_of", "by", "quantity, revenue")
+	}
+	if thresholdA <= 0 || thresholdA > thresholdB || thresholdB > 100 {
+		return nil, apperror.Validation("report.abc_thresholds")
+	}
+
+	products, err := s.repo.GetProductSales(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// sales are net of refunds; a product refunded more than it sold in
+	// the period weighs nothing rather than taking from the others
+	totalQty, totalRevenue := 0, 0
+	for _, p := range products {
+		totalQty += max(0, p.Quantity)
+		totalRevenue += max(0, p.Revenue)
+	}
+	total := rankValue(totalQty, totalRevenue, by)
+
+	rankProducts(products, by)
+
+	report := &models.AbcReport{
+		StartDate:  start.Format(clock.DateLayout),
+		EndDate:    end.Format(clock.DateLayout),
+		By:         by,
+		ThresholdA: thresholdA,
+		ThresholdB: thresholdB,
+		Classes: []models.AbcClassSummary{
+			{Class: "A"}, {Class: "B"}, {Class: "C"},
+		},
+		Products: make([]models.AbcItem, 0, len(products)),
+	}
+
+	cumulative := 0
+	for i, p := range products {
+		p.QuantityShare = share(p.Quantity, totalQty)
+		p.RevenueShare = share(p.Revenue, totalRevenue)
+
+		// a product belongs to the class in which its cumulative share
+		// starts, so the product crossing the A threshold is still A
+		startShare := 0.0
+		if total > 0 {
+			startShare = float64(cumulative) / float64(total) * 100
+		}
+		value := max(0, rankValue(p.Quantity, p.Revenue, by))
+		cumulative += value
+
+		class := 2
+		if value > 0 {
+			switch {
+			case startShare < thresholdA:
+				class = 0
+			case startShare < thresholdB:
+				class = 1
+			}
+		}
+
+		report.Products = append(report.Products, models.AbcItem{
+			ProductSales:    p,
+			Rank:            i + 1,
+			CumulativeShare: share(cumulative, total),
+			Class:           report.Classes[class].Class,
+		})
+
+		summary := &report.Classes[class]
+		summary.Products++
+		summary.Quantity += p.Quantity
+		summary.Revenue += p.Revenue
+	}
+
+	for i := range report.Classes {
+		c := &report.Classes[i]
+		c.ProductShare = share(c.Products, len(products))
+		c.Share = share(rankValue(c.Quantity, c.Revenue, by), total)
+	}
+
+	return report, nil
+}
+
+// GetDeadStock lists products in stock that did not sell in the last days
+// business days (dead) or sold at most slowThreshold units (slow)
+func (s *ReportService) GetDeadStock(days, slowThreshold int) (*models.DeadStockReport, error) {
+	if days <= 0 {
+		return nil, apperror.Validation("field.not_positive", "days")
+	}
+	if slowThreshold < 0 {
+		return nil, apperror.Validation("field.negative", "slow_threshold")
+	}
+
+	today := s.calendar.Today()
+	since := today.AddDate(0, 0, -(days - 1))
+
+	items, err := s.repo.GetStockAging(since)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DeadStockReport{
+		Days:          days,
+		Since:         since.Format(clock.DateLayout),
+		SlowThreshold: slowThreshold,
+		Dead:          []models.StockAgingItem{},
+		Slow:          []models.StockAgingItem{},
+	}
+
+	for _, item := range items {
+		if item.LastSoldAt != nil {
+			elapsed := clock.DaysBetween(s.calendar.DateOf(*item.LastSoldAt), today)
+			item.DaysSinceLastSale = &elapsed
+		}
+
+		switch {
+		case item.QuantitySold == 0:
+			report.Dead = append(report.Dead, item)
+			report.DeadStockValue += item.StockValue
+			report.DeadCostValue += item.CostValue
+		case item.QuantitySold <= slowThreshold:
+			report.Slow = append(report.Slow, item)
+			report.SlowStockValue += item.StockValue
+			report.SlowCostValue += item.CostValue
+		}
+	}
+
+	// most money tied up first
+	for _, list := range [][]models.StockAgingItem{report.Dead, report.Slow} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].StockValue > list[j].StockValue
+		})
+	}
+
+	return report, nil
+}
+
+// GetInventoryValuation values the stock on hand at the end of business
+// day asOf, per product and per category
+func (s *ReportService) GetInventoryValuation(asOf time.Time) (*models.InventoryValuation, error) {
+	if asOf.Format(clock.DateLayout) > s.calendar.Today().Format(clock.DateLayout) {
+		return nil, apperror.Validation("field.not_future", "as_of")
+	}
+
+	end, items, err := s.repo.GetInventoryValuation(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &models.InventoryValuation{
+		AsOf:       asOf.Format(clock.DateLayout),
+		AsOfTime:   end.In(s.calendar.Location),
+		Categories: []models.CategoryValuation{},
+		Products:   items,
+	}
+
+	index := map[int]int{}
+	for _, item := range items {
+		i, ok := index[item.CategoryID]
+		if !ok {
+			name := item.CategoryName
+			if name == "" {
+				name = "Tanpa kategori"
+			}
+			valuation.Categories = append(valuation.Categories, models.CategoryValuation{
+				CategoryID: item.CategoryID,
+				Name:       name,
+			})
+			i = len(valuation.Categories) - 1
+			index[item.CategoryID] = i
+		}
+
+		c := &valuation.Categories[i]
+		c.Products++
+		c.Quantity += item.Quantity
+		c.CostValue += item.CostValue
+		c.RetailValue += item.RetailValue
+
+		valuation.TotalQuantity += item.Quantity
+		valuation.TotalCostValue += item.CostValue
+		valuation.TotalRetailValue += item.RetailValue
+	}
+
+	sort.SliceStable(valuation.Categories, func(i, j int) bool {
+		return valuation.Categories[i].CostValue > valuation.Categories[j].CostValue
+	})
+
+	return valuation, nil
+}
+
+// nextBucket returns the first day of the bucket following the one containing t.
+// Weeks start on Monday.
+func nextBucket(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, 7-offset)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// bucketLabel names a bucket, e.g. 2026-10-19, 2026-W43 or 2026-10
+func bucketLabel(t time.Time, interval string) string {
+	switch interval {
+	case IntervalWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case IntervalMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format(clock.DateLayout)
+	}
+}
+
+func averageBasket(revenue, transactions int) float64 {
+	if transactions <= 0 {
+		return 0
+	}
+	return round2(float64(revenue) / float64(transactions))
+}
+
+// percentChange returns nil when there is no previous value to compare with
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := round2((current - previous) / previous * 100)
+	return &change
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// =======================
+// MARKET BASKET
+// =======================
+// GetBasketAnalysis returns the product pairs most strongly bought
+// together in the period. Pairs seen in fewer than minCount transactions
+// are left out to keep one-off coincidences from topping the list.
+func (s *ReportService) GetBasketAnalysis(start, end time.Time, minCount, limit int) (*models.BasketAnalysis, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+	if minCount < 1 {
+		return nil, apperror.Validation("field.min", "min_count", 1)
+	}
+	if limit < 1 {
+		return nil, apperror.Validation("field.min", "limit", 1)
+	}
+
+	baskets, names, err := s.repo.GetBaskets(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := analytics.AnalyzeBaskets(baskets, minCount)
+
+	result := &models.BasketAnalysis{
+		StartDate:    start.Format(clock.DateLayout),
+		EndDate:      end.Format(clock.DateLayout),
+		Transactions: stats.Baskets,
+		MinCount:     minCount,
+		Pairs:        []models.ProductPair{},
+	}
+
+	for _, p := range stats.Pairs {
+		if len(result.Pairs) == limit {
+			break
+		}
+		result.Pairs = append(result.Pairs, models.ProductPair{
+			ProductAID:     p.A,
+			ProductAName:   names[p.A],
+			ProductBID:     p.B,
+			ProductBName:   names[p.B],
+			Transactions:   p.Count,
+			Support:        round4(p.Support),
+			ConfidenceAToB: round4(p.ConfidenceAB),
+			ConfidenceBToA: round4(p.ConfidenceBA),
+			Lift:           round4(p.Lift),
+		})
+	}
+
+	return result, nil
+}
+
+// GetBoughtTogether returns the products most often bought with the given
+// product, by confidence and then lift
+func (s *ReportService) GetBoughtTogether(productID int, start, end time.Time, minCount, limit int) (*models.BoughtTogether, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+	if minCount < 1 {
+		return nil, apperror.Validation("field.min", "min_count", 1)
+	}
+	if limit < 1 {
+		return nil, apperror.Validation("field.min", "limit", 1)
+	}
+
+	baskets, names, err := s.repo.GetBaskets(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := analytics.AnalyzeBaskets(baskets, minCount)
+
+	result := &models.BoughtTogether{
+		ProductID:    productID,
+		Name:         names[productID],
+		StartDate:    start.Format(clock.DateLayout),
+		EndDate:      end.Format(clock.DateLayout),
+		Transactions: stats.ItemCounts[productID],
+		Associations: []models.Association{},
+	}
+
+	for _, p := range stats.Pairs {
+		other, confidence := p.B, p.ConfidenceAB
+		switch productID {
+		case p.A:
+		case p.B:
+			other, confidence = p.A, p.ConfidenceBA
+		default:
+			continue
+		}
+
+		result.Associations = append(result.Associations, models.Association{
+			ProductID:    other,
+			Name:         names[other],
+			Transactions: p.Count,
+			Support:      round4(p.Support),
+			Confidence:   round4(confidence),
+			Lift:         round4(p.Lift),
+		})
+	}
+
+	// pairs come sorted by lift; the question here is "what else goes in
+	// the basket", so confidence leads
+	sort.SliceStable(result.Associations, func(i, j int) bool {
+		return result.Associations[i].Confidence > result.Associations[j].Confidence
+	})
+	if len(result.Associations) > limit {
+		result.Associations = result.Associations[:limit]
+	}
+
+	return result, nil
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// =======================
+// CASHIER PERFORMANCE
+// =======================
+// GetCashierReport returns the sales, voids and average basket of every
+// cashier per shift in the period, and the same summed per cashier
+func (s *ReportService) GetCashierReport(start, end time.Time) (*models.CashierReport, error) {
+	if end.Before(start) {
+		return nil, apperror.Validation("field.not_before", "end_date", "start_date")
+	}
+
+	shifts, err := s.repo.GetCashierSales(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CashierReport{
+		StartDate: start.Format(clock.DateLayout),
+		EndDate:   end.Format(clock.DateLayout),
+		Shifts:    shifts,
+		Cashiers:  []models.CashierShiftSales{},
+	}
+
+	// sales without a cashier are grouped under user id 0
+	totals := map[int]int{}
+	for i := range report.Shifts {
+		row := &report.Shifts[i]
+		finishCashierSales(row)
+
+		key := 0
+		if row.UserID != nil {
+			key = *row.UserID
+		}
+		idx, ok := totals[key]
+		if !ok {
+			idx = len(report.Cashiers)
+			totals[key] = idx
+			report.Cashiers = append(report.Cashiers, models.CashierShiftSales{
+				UserID:     row.UserID,
+				EmployeeID: row.EmployeeID,
+				Cashier:    row.Cashier,
+			})
+		}
+		total := &report.Cashiers[idx]
+		total.Transactions += row.Transactions
+		total.Sales += row.Sales
+		total.Voids += row.Voids
+		total.VoidAmount += row.VoidAmount
+	}
+
+	for i := range report.Cashiers {
+		finishCashierSales(&report.Cashiers[i])
+	}
+	sort.SliceStable(report.Cashiers, func(i, j int) bool {
+		return report.Cashiers[i].NetSales > report.Cashiers[j].NetSales
+	})
+
+	return report, nil
 }
 
-func (s *ReportService) GetSummary(startDate, endDate string) (interface{}, error) {
-	return s.repo.GetSummary(startDate, endDate)
+// finishCashierSales fills in the figures derived from the counts
+func finishCashierSales(c *models.CashierShiftSales) {
+	c.NetSales = c.Sales - c.VoidAmount
+	if c.Transactions > 0 {
+		c.AverageBasket = round2(float64(c.Sales) / float64(c.Transactions))
+	}
 }
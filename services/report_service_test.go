@@ -0,0 +1,307 @@
+package services
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	_ "time/tzdata" // the test timezones, also where the system has none
+
+	"task-crud-kategori/clock"
+	"task-crud-kategori/database"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+)
+
+// newTestStore opens a migrated database in a temporary directory
+func newTestStore(t *testing.T) (*sql.DB, *clock.Calendar) {
+	t.Helper()
+
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	calendar, err := clock.NewCalendar("Asia/Jakarta", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, calendar
+}
+
+// addProduct creates a product in stock in a new category
+func addProduct(t *testing.T, db *sql.DB, name string, price int) int {
+	t.Helper()
+
+	res, err := db.Exec("INSERT INTO categories (name) VALUES (?)", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	categoryID, _ := res.LastInsertId()
+
+	res, err = db.Exec("INSERT INTO products (name, price, stock, category_id) VALUES (?, ?, 100, ?)", name, price, categoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// unaudited stands in for the audit log in tests that do not look at it
+func unaudited(*sql.Tx, int, interface{}) error { return nil }
+
+// sell rings up quantity of a product and returns the transaction id
+func sell(t *testing.T, repo *repositories.TransactionRepository, productID, quantity int) int {
+	t.Helper()
+
+	transaction, err := repo.CreateTransaction(models.CheckoutRequest{
+		Items:         []models.CheckoutItem{{ProductID: productID, Quantity: quantity}},
+		PaymentMethod: models.PaymentCash,
+	}, unaudited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transaction.ID
+}
+
+func refund(t *testing.T, repo *repositories.TransactionRepository, transactionID int) {
+	t.Helper()
+
+	if _, err := repo.Refund(transactionID, models.RefundRequest{Reason: "test"}, unaudited); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// forEachPath runs check on the raw transactions, then again on freshly
+// built rollups; both must give the same reports
+func forEachPath(t *testing.T, db *sql.DB, calendar *clock.Calendar, check func(t *testing.T)) {
+	t.Run("transactions", check)
+
+	if _, err := repositories.NewRollupRepository(db, calendar).Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("rollups", check)
+}
+
+func TestGetSummaryBreakdownsAfterRefund(t *testing.T) {
+	db, calendar := newTestStore(t)
+	transactions := repositories.NewTransactionRepository(db, calendar)
+	service := NewReportService(repositories.NewReportRepository(db, calendar), calendar)
+
+	kopi := addProduct(t, db, "Kopi", 100)
+	teh := addProduct(t, db, "Teh", 50)
+
+	sell(t, transactions, kopi, 1)
+	refund(t, transactions, sell(t, transactions, kopi, 2))
+	sell(t, transactions, teh, 2)
+
+	today := calendar.Today()
+	opts := models.ReportOptions{Top: 5, Bottom: 5, RankBy: RankByQuantity, ByCategory: true}
+
+	forEachPath(t, db, calendar, func(t *testing.T) {
+		summary, err := service.GetSummary(today, today, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if summary.TotalRevenue != 200 || summary.TotalTransaksi != 2 {
+			t.Errorf("revenue %d, transactions %d; want 200, 2", summary.TotalRevenue, summary.TotalTransaksi)
+		}
+
+		want := []models.ProductSales{
+			{ProductID: teh, Name: "Teh", Quantity: 2, Revenue: 100},
+			{ProductID: kopi, Name: "Kopi", Quantity: 1, Revenue: 100},
+		}
+		if len(summary.TopProducts) != len(want) {
+			t.Fatalf("got %d top products, want %d", len(summary.TopProducts), len(want))
+		}
+		for i, w := range want {
+			got := summary.TopProducts[i]
+			if got.ProductID != w.ProductID || got.Quantity != w.Quantity || got.Revenue != w.Revenue {
+				t.Errorf("top product %d = %s qty %d revenue %d; want %s qty %d revenue %d",
+					i, got.Name, got.Quantity, got.Revenue, w.Name, w.Quantity, w.Revenue)
+			}
+		}
+
+		best := summary.TopProducts[0]
+		if summary.ProdukTerlaris.Nama != best.Name || summary.ProdukTerlaris.QtyTerjual != best.Quantity {
+			t.Errorf("best product %+v does not match top product %s qty %d", summary.ProdukTerlaris, best.Name, best.Quantity)
+		}
+
+		// no discounts, so the breakdowns add up to the net revenue
+		for name, parts := range map[string][]int{
+			"top_products":    revenues(summary.TopProducts),
+			"bottom_products": revenues(summary.BottomProducts),
+			"categories":      categoryRevenues(summary.Categories),
+		} {
+			total := 0
+			for _, revenue := range parts {
+				total += revenue
+			}
+			if total != summary.TotalRevenue {
+				t.Errorf("%s add up to %d, want total_revenue %d", name, total, summary.TotalRevenue)
+			}
+		}
+	})
+}
+
+func revenues(products []models.ProductSales) []int {
+	values := make([]int, len(products))
+	for i, p := range products {
+		values[i] = p.Revenue
+	}
+	return values
+}
+
+func categoryRevenues(categories []models.CategorySales) []int {
+	values := make([]int, len(categories))
+	for i, c := range categories {
+		values[i] = c.Revenue
+	}
+	return values
+}
+
+func TestGetABC(t *testing.T) {
+	db, calendar := newTestStore(t)
+	transactions := repositories.NewTransactionRepository(db, calendar)
+	service := NewReportService(repositories.NewReportRepository(db, calendar), calendar)
+
+	gula := addProduct(t, db, "Gula", 100)
+	beras := addProduct(t, db, "Beras", 100)
+	garam := addProduct(t, db, "Garam", 100)
+	minyak := addProduct(t, db, "Minyak", 100)
+
+	// gross, gula sold most; net of its refund it is a B product
+	sell(t, transactions, beras, 16)
+	refund(t, transactions, sell(t, transactions, gula, 10))
+	sell(t, transactions, gula, 3)
+	sell(t, transactions, garam, 1)
+	// sold yesterday and refunded today: minyak nets negative today
+	yesterday := sell(t, transactions, minyak, 5)
+	if _, err := db.Exec("UPDATE transactions SET created_at = datetime(created_at, '-1 day') WHERE id = ?", yesterday); err != nil {
+		t.Fatal(err)
+	}
+	refund(t, transactions, yesterday)
+
+	today := calendar.Today()
+
+	tests := []struct {
+		name    string
+		by      string
+		classes map[int]string
+		share   map[int]float64 // cumulative share
+	}{
+		{
+			name:    "by revenue",
+			by:      RankByRevenue,
+			classes: map[int]string{beras: "A", gula: "B", garam: "C", minyak: "C"},
+			share:   map[int]float64{beras: 80, gula: 95, garam: 100, minyak: 100},
+		},
+		{
+			name:    "by quantity",
+			by:      RankByQuantity,
+			classes: map[int]string{beras: "A", gula: "B", garam: "C", minyak: "C"},
+			share:   map[int]float64{beras: 80, gula: 95, garam: 100, minyak: 100},
+		},
+	}
+
+	forEachPath(t, db, calendar, func(t *testing.T) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				report, err := service.GetABC(today, today, tt.by, 80, 95)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, p := range report.Products {
+					if p.Class != tt.classes[p.ProductID] {
+						t.Errorf("%s in class %s, want %s", p.Name, p.Class, tt.classes[p.ProductID])
+					}
+					if p.CumulativeShare != tt.share[p.ProductID] {
+						t.Errorf("%s cumulative share %v, want %v", p.Name, p.CumulativeShare, tt.share[p.ProductID])
+					}
+				}
+
+				// the classes split the same net revenue the summary reports,
+				// refunds of earlier sales included
+				summary, err := service.GetSummary(today, today, models.ReportOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				revenue := 0
+				for _, c := range report.Classes {
+					revenue += c.Revenue
+				}
+				if revenue != summary.TotalRevenue {
+					t.Errorf("classes add up to %d, want total_revenue %d", revenue, summary.TotalRevenue)
+				}
+			})
+		}
+	})
+}
+
+func TestGetTimeSeriesPreviousPeriodAcrossDST(t *testing.T) {
+	db, _ := newTestStore(t)
+	// New York moves to summer time on 8 March 2026, a 23 hour day
+	calendar, err := clock.NewCalendar("America/New_York", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewReportService(repositories.NewReportRepository(db, calendar), calendar)
+
+	start, _ := calendar.ParseDate("2026-03-08")
+	end, _ := calendar.ParseDate("2026-03-14")
+	report, err := service.GetTimeSeries(start, end, IntervalDay, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := report.Previous
+	if previous.StartDate != "2026-03-01" || previous.EndDate != "2026-03-07" || len(previous.Buckets) != 7 {
+		t.Errorf("previous period %s to %s with %d buckets, want 2026-03-01 to 2026-03-07 with 7",
+			previous.StartDate, previous.EndDate, len(previous.Buckets))
+	}
+}
+
+func TestGetHeatmapMatchesSummary(t *testing.T) {
+	db, calendar := newTestStore(t)
+	transactions := repositories.NewTransactionRepository(db, calendar)
+	service := NewReportService(repositories.NewReportRepository(db, calendar), calendar)
+
+	kopi := addProduct(t, db, "Kopi", 100)
+	sell(t, transactions, kopi, 3)
+	refund(t, transactions, sell(t, transactions, kopi, 2))
+	// sold yesterday, refunded today
+	yesterday := sell(t, transactions, kopi, 1)
+	if _, err := db.Exec("UPDATE transactions SET created_at = datetime(created_at, '-1 day') WHERE id = ?", yesterday); err != nil {
+		t.Fatal(err)
+	}
+	refund(t, transactions, yesterday)
+
+	today := calendar.Today()
+	summary, err := service.GetSummary(today, today, models.ReportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	heatmap, err := service.GetHeatmap(today, today, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revenue, count := 0, 0
+	for _, c := range heatmap.Cells {
+		revenue += c.Revenue
+		count += c.Transactions
+	}
+	if revenue != summary.TotalRevenue || count != summary.TotalTransaksi {
+		t.Errorf("heatmap revenue %d, transactions %d; summary %d, %d",
+			revenue, count, summary.TotalRevenue, summary.TotalTransaksi)
+	}
+	if summary.TotalRevenue != 200 {
+		t.Errorf("total_revenue %d, want 200", summary.TotalRevenue)
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"log"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+)
+
+// RollupService rebuilds and verifies the daily sales rollups
+type RollupService struct {
+	repo *repositories.RollupRepository
+}
+
+// NewRollupService creates a new instance of RollupService
+func NewRollupService(repo *repositories.RollupRepository) *RollupService {
+	return &RollupService{repo: repo}
+}
+
+// Rebuild recomputes all rollups, returning the number of days written
+func (s *RollupService) Rebuild() (int, error) {
+	return s.repo.Rebuild()
+}
+
+// Check compares the rollups with the raw transactions
+func (s *RollupService) Check() (*models.RollupCheck, error) {
+	return s.repo.Check()
+}
+
+// EnsureCurrent rebuilds the rollups when they were never built or were
+// built with another timezone or cut-off hour
+func (s *RollupService) EnsureCurrent() error {
+	current, err := s.repo.IsCurrent()
+	if err != nil || current {
+		return err
+	}
+
+	log.Println("Sales rollups are not current, rebuilding")
+	days, err := s.repo.Rebuild()
+	if err != nil {
+		return err
+	}
+	log.Printf("Sales rollups rebuilt for %d business days", days)
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// ShiftService handles opening and closing register shifts
+type ShiftService struct {
+	repo *repositories.ShiftRepository
+}
+
+// NewShiftService creates a new instance of ShiftService
+func NewShiftService(repo *repositories.ShiftRepository) *ShiftService {
+	return &ShiftService{repo: repo}
+}
+
+// Open starts a shift with the cash float in the drawer
+func (s *ShiftService) Open(req models.OpenShiftRequest) (*models.Shift, error) {
+	v := validate.New()
+	v.NotNegative("opening_float", req.OpeningFloat)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	shift := &models.Shift{
+		OpenedBy:     req.OpenedBy,
+		OpeningFloat: req.OpeningFloat,
+	}
+	if err := s.repo.Open(shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetAll lists shifts, newest first
+func (s *ShiftService) GetAll() ([]models.Shift, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID retrieves a shift by its ID
+func (s *ShiftService) GetByID(id int) (*models.Shift, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetCurrent retrieves the open shift
+func (s *ShiftService) GetCurrent() (*models.Shift, error) {
+	return s.repo.GetCurrent()
+}
+
+// XReport returns a mid-shift snapshot
+func (s *ShiftService) XReport(id int) (*models.ShiftReport, error) {
+	return s.repo.XReport(id)
+}
+
+// Close ends a shift and produces its Z-report
+func (s *ShiftService) Close(id int, req models.CloseShiftRequest) (*models.ShiftReport, error) {
+	v := validate.New()
+	v.NotNegative("counted_cash", req.CountedCash)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Close(id, req)
+}
+
+// GetZReports lists all Z-reports, newest first
+func (s *ShiftService) GetZReports() ([]models.ShiftReport, error) {
+	return s.repo.GetZReports()
+}
+
+// GetZReport retrieves a Z-report by its number
+func (s *ShiftService) GetZReport(number int) (*models.ShiftReport, error) {
+	return s.repo.GetZReport(number)
+}
@@ -1,53 +1,100 @@
 package services
 
 import (
-	"database/sql"
-	"errors"
+	"fmt"
+	"task-crud-kategori/clock"
 	"task-crud-kategori/models"
 	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+	"time"
 )
 
 // TransactionService handles transaction-related operations.
 type TransactionService struct {
-	db   *sql.DB
-	repo *repositories.TransactionRepository
+	repo     *repositories.TransactionRepository
+	audit    *AuditService
+	calendar *clock.Calendar
 }
 
 func NewTransactionService(
-	db *sql.DB,
 	repo *repositories.TransactionRepository,
+	audit *AuditService,
+	calendar *clock.Calendar,
 ) *TransactionService {
 	return &TransactionService{
-		db:   db,
-		repo: repo,
+		repo:     repo,
+		audit:    audit,
+		calendar: calendar,
 	}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	if len(items) == 0 {
-		return nil, errors.New("checkout items cannot be empty")
-	}
+// ParseDate parses a YYYY-MM-DD business date in the store timezone
+func (s *TransactionService) ParseDate(value string) (time.Time, error) {
+	return s.calendar.ParseDate(value)
+}
 
-	tx, err := s.db.Begin()
-	if err != nil {
+func (s *TransactionService) Checkout(req models.CheckoutRequest, actor models.Actor) (*models.Transaction, error) {
+	v := validate.New()
+	validateItems(v, "items", req.Items)
+	prepareCheckout(v, &req)
+	if err := v.Err(); err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	// the repository runs the whole sale in one database transaction
+	return s.repo.CreateTransaction(req, s.audit.Record(actor, models.AuditCreate, models.EntityTransaction, nil))
+}
 
-	transaction, err := s.repo.CreateTransaction(items)
-	if err != nil {
-		return nil, err
+// validateItems checks the lines of a sale: at least one, each for a
+// product with a positive quantity, and no product on two lines
+func validateItems(v *validate.Validator, field string, items []models.CheckoutItem) {
+	if len(items) == 0 {
+		v.Add(field, validate.Required, "field.empty", field)
+		return
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
+	lines := map[int]int{}
+	for i, item := range items {
+		line := fmt.Sprintf("%s[%d]", field, i)
+		if item.ProductID <= 0 {
+			v.Add(line+".product_id", validate.Required, "field.required", line+".product_id")
+		} else if first, ok := lines[item.ProductID]; ok {
+			v.Add(line+".product_id", validate.Duplicate,
+				"checkout.duplicate_item", item.ProductID, field, first)
+		} else {
+			lines[item.ProductID] = i
+		}
+		v.Positive(line+".quantity", item.Quantity)
+		if item.Price != nil {
+			v.NotNegative(line+".price", *item.Price)
+		}
 	}
+}
+
+// prepareCheckout checks the payment side of a checkout and fills in
+// the defaults
+func prepareCheckout(v *validate.Validator, req *models.CheckoutRequest) {
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = models.PaymentCash
+	}
+	v.Check(models.IsValidPaymentMethod(req.PaymentMethod), "payment_method", validate.Invalid, "checkout.invalid_payment_method")
+	v.NotNegative("discount", req.Discount)
+	v.NotNegative("redeem_points", req.RedeemPoints)
+	req.VoucherCode = NormalizeVoucherCode(req.VoucherCode)
+}
+
+// GetAll lists transactions of the business days from startDate to endDate
+func (s *TransactionService) GetAll(startDate, endDate time.Time) ([]models.Transaction, error) {
+	return s.repo.GetAll(startDate, endDate)
+}
+
+// GetByID returns a transaction with its details
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
 
-	return transaction, nil
+// Refund reverses a transaction and restocks its items. The audit entry
+// is recorded on the transaction and holds the refund.
+func (s *TransactionService) Refund(id int, req models.RefundRequest, actor models.Actor) (*models.Refund, error) {
+	return s.repo.Refund(id, req, s.audit.Record(actor, models.AuditRefund, models.EntityTransaction, nil))
 }
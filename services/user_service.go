@@ -0,0 +1,195 @@
+package services
+
+import (
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/auth"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// UserService manages staff accounts
+type UserService struct {
+	repo *repositories.UserRepository
+}
+
+// NewUserService creates a new instance of UserService
+func NewUserService(repo *repositories.UserRepository) *UserService {
+	return &UserService{repo: repo}
+}
+
+// GetAll lists all users
+func (s *UserService) GetAll() ([]models.User, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID retrieves a user by their ID
+func (s *UserService) GetByID(id int) (*models.User, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create adds a user. New users are active cashiers unless the request
+// says otherwise.
+func (s *UserService) Create(req models.UserRequest) (*models.User, error) {
+	user := &models.User{
+		Username: strings.TrimSpace(req.Username),
+		Name:     strings.TrimSpace(req.Name),
+		Role:     req.Role,
+		Active:   true,
+	}
+	if user.Role == "" {
+		user.Role = models.RoleCashier
+	}
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+
+	v := validate.New()
+	validateUser(v, user)
+	validatePassword(v, "password", req.Password)
+	if req.PIN != "" {
+		validatePIN(v, req.PIN)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	var pinHash string
+	if req.PIN != "" {
+		if pinHash, err = auth.HashPIN(req.PIN); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Create(user, hash); err != nil {
+		return nil, err
+	}
+	if pinHash != "" {
+		if err := s.repo.SetPIN(user.ID, pinHash); err != nil {
+			return nil, err
+		}
+		user.HasPIN = true
+	}
+	return user, nil
+}
+
+// Update changes a user; fields left empty in req keep their value. A new
+// password signs the user out everywhere. Users cannot change their own
+// role or deactivate themselves, so the last owner cannot lock everyone out.
+func (s *UserService) Update(id int, req models.UserRequest, currentUserID int) (*models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Username != "" {
+		user.Username = strings.TrimSpace(req.Username)
+	}
+	if req.Name != "" {
+		user.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Role != "" {
+		if id == currentUserID && req.Role != user.Role {
+			return nil, apperror.Forbidden("user.own_role")
+		}
+		user.Role = req.Role
+	}
+	if req.Active != nil {
+		if id == currentUserID && !*req.Active {
+			return nil, apperror.Forbidden("user.own_deactivate")
+		}
+		user.Active = *req.Active
+	}
+
+	v := validate.New()
+	validateUser(v, user)
+	if req.Password != "" {
+		validatePassword(v, "password", req.Password)
+	}
+	if req.PIN != "" {
+		validatePIN(v, req.PIN)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	var hash, pinHash string
+	if req.Password != "" {
+		if hash, err = auth.HashPassword(req.Password); err != nil {
+			return nil, err
+		}
+	}
+	if req.PIN != "" {
+		if pinHash, err = auth.HashPIN(req.PIN); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	if hash != "" {
+		if err := s.repo.SetPassword(user.ID, hash, 0); err != nil {
+			return nil, err
+		}
+	}
+	if pinHash != "" {
+		if err := s.repo.SetPIN(user.ID, pinHash); err != nil {
+			return nil, err
+		}
+		user.HasPIN = true
+	}
+	return user, nil
+}
+
+// ChangePassword changes the password of a signed in user after checking
+// the current one. Their other sessions are signed out.
+func (s *UserService) ChangePassword(userID, sessionID int, req models.PasswordChangeRequest) error {
+	current, err := s.repo.GetPasswordHash(userID)
+	if err != nil {
+		return err
+	}
+	v := validate.New()
+	v.Check(auth.CheckPassword(current, req.CurrentPassword), "current_password", validate.Invalid, "user.wrong_password")
+	validatePassword(v, "new_password", req.NewPassword)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetPassword(userID, hash, sessionID)
+}
+
+// Delete removes a user; nobody can delete their own account
+func (s *UserService) Delete(id, currentUserID int) error {
+	if id == currentUserID {
+		return apperror.Forbidden("user.own_delete")
+	}
+	return s.repo.Delete(id)
+}
+
+func validateUser(v *validate.Validator, user *models.User) {
+	v.Required("username", user.Username)
+	v.Check(!strings.ContainsAny(user.Username, " \t\n"), "username", validate.Invalid, "user.username_spaces")
+	v.Check(models.IsValidRole(user.Role), "role", validate.Invalid, "user.invalid_role")
+	if user.Name == "" {
+		user.Name = user.Username
+	}
+}
+
+func validatePassword(v *validate.Validator, field, password string) {
+	v.Check(len(password) >= auth.MinPasswordLength, field, validate.Invalid,
+		"field.too_short", field, auth.MinPasswordLength)
+}
+
+func validatePIN(v *validate.Validator, pin string) {
+	v.Check(auth.ValidPIN(pin), "pin", validate.Invalid, "pin.invalid")
+}
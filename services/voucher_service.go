@@ -0,0 +1,97 @@
+package services
+
+import (
+	"strings"
+	"task-crud-kategori/models"
+	"task-crud-kategori/repositories"
+	"task-crud-kategori/validate"
+)
+
+// VoucherService manages coupon codes
+type VoucherService struct {
+	repo *repositories.VoucherRepository
+}
+
+// NewVoucherService creates a new instance of VoucherService
+func NewVoucherService(repo *repositories.VoucherRepository) *VoucherService {
+	return &VoucherService{repo: repo}
+}
+
+// GetAll lists all vouchers, newest first
+func (s *VoucherService) GetAll() ([]models.Voucher, error) {
+	return s.repo.GetAll()
+}
+
+// Create adds a new voucher
+func (s *VoucherService) Create(voucher *models.Voucher) error {
+	voucher.Code = NormalizeVoucherCode(voucher.Code)
+	v := validate.New()
+	v.Required("code", voucher.Code)
+	if err := validateVoucher(v, voucher); err != nil {
+		return err
+	}
+	return s.repo.Create(voucher)
+}
+
+// GetByID retrieves a voucher by its ID
+func (s *VoucherService) GetByID(id int) (*models.Voucher, error) {
+	return s.repo.GetByID(id)
+}
+
+// Update changes the terms of a voucher
+func (s *VoucherService) Update(voucher *models.Voucher) error {
+	if err := validateVoucher(validate.New(), voucher); err != nil {
+		return err
+	}
+	return s.repo.Update(voucher)
+}
+
+// Delete removes a voucher that was never redeemed
+func (s *VoucherService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// GetRedemptions lists the uses of a voucher
+func (s *VoucherService) GetRedemptions(id int) ([]models.VoucherRedemption, error) {
+	return s.repo.GetRedemptions(id)
+}
+
+// NormalizeVoucherCode makes codes case-insensitive
+func NormalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validateVoucher(v *validate.Validator, voucher *models.Voucher) error {
+	switch voucher.Type {
+	case models.VoucherFixed:
+	case models.VoucherPercent:
+		v.Check(voucher.Value <= 100, "value", validate.OutOfRange, "voucher.percent_over_100")
+	default:
+		v.Add("type", validate.Invalid, "voucher.invalid_type")
+	}
+
+	v.Positive("value", voucher.Value)
+	v.NotNegative("max_discount", voucher.MaxDiscount)
+	v.NotNegative("min_spend", voucher.MinSpend)
+	v.NotNegative("usage_limit", voucher.UsageLimit)
+	v.NotNegative("per_customer_limit", voucher.PerCustomerLimit)
+
+	if voucher.ValidFrom != nil {
+		v.Date("valid_from", *voucher.ValidFrom)
+	}
+	if voucher.ValidUntil != nil {
+		v.Date("valid_until", *voucher.ValidUntil)
+	}
+	if voucher.ValidFrom != nil && voucher.ValidUntil != nil && v.Valid("valid_from") && v.Valid("valid_until") {
+		v.Check(*voucher.ValidUntil >= *voucher.ValidFrom, "valid_until", validate.OutOfRange,
+			"field.not_before", "valid_until", "valid_from")
+	}
+
+	if voucher.ProductIDs == nil {
+		voucher.ProductIDs = []int{}
+	}
+	if voucher.CategoryIDs == nil {
+		voucher.CategoryIDs = []int{}
+	}
+	return v.Err()
+}
@@ -0,0 +1,101 @@
+// Package validate collects what is wrong with the fields of a request, so
+// a client learns about every invalid field at once instead of one per
+// attempt. Services validate their input with it before writing anything.
+package validate
+
+import (
+	"strings"
+	"task-crud-kategori/apperror"
+	"task-crud-kategori/clock"
+	"time"
+)
+
+// Field error codes, for clients to switch on
+const (
+	Required    = "required"
+	Invalid     = "invalid"
+	Negative    = "negative"
+	NotPositive = "not_positive"
+	OutOfRange  = "out_of_range"
+	Duplicate   = "duplicate"
+	// Unknown is an id of a record that does not exist
+	Unknown = "unknown"
+)
+
+// Validator collects field errors
+type Validator struct {
+	fields []apperror.FieldError
+}
+
+// New returns a Validator without errors
+func New() *Validator {
+	return &Validator{}
+}
+
+// Add records an error on field, named by its JSON path such as
+// items[2].quantity, with the message for an i18n key
+func (v *Validator) Add(field, code, key string, args ...any) {
+	v.fields = append(v.fields, apperror.NewFieldError(field, code, key, args...))
+}
+
+// Check records an error on field unless ok
+func (v *Validator) Check(ok bool, field, code, key string, args ...any) {
+	if !ok {
+		v.Add(field, code, key, args...)
+	}
+}
+
+// Required checks that value is not blank
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, Required, "field.required", field)
+}
+
+// NotNegative checks that value is 0 or more
+func (v *Validator) NotNegative(field string, value int) {
+	v.Check(value >= 0, field, Negative, "field.negative", field)
+}
+
+// Positive checks that value is more than 0
+func (v *Validator) Positive(field string, value int) {
+	v.Check(value > 0, field, NotPositive, "field.not_positive", field)
+}
+
+// Range checks that value is between min and max
+func (v *Validator) Range(field string, value, min, max float64) {
+	v.Check(value >= min && value <= max, field, OutOfRange, "field.out_of_range", field, min, max)
+}
+
+// Date checks that value is empty or a YYYY-MM-DD date
+func (v *Validator) Date(field, value string) {
+	if value == "" {
+		return
+	}
+	_, err := time.Parse(clock.DateLayout, value)
+	v.Check(err == nil, field, Invalid, "field.date", field)
+}
+
+// Valid reports whether field has no error so far, for checks that only
+// make sense on a valid value
+func (v *Validator) Valid(field string) bool {
+	for _, f := range v.fields {
+		if f.Field == field {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns a validation error listing every field error, or nil when
+// there are none
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apperror.Fields(v.fields)
+}
+
+// Field returns a validation error on one field, for checks that can only
+// be made deep in a write, such as a discount against the sale's total
+func Field(field, code, key string, args ...any) *apperror.Error {
+	return apperror.Fields([]apperror.FieldError{apperror.NewFieldError(field, code, key, args...)})
+}